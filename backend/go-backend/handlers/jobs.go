@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -20,6 +21,27 @@ func getUserID(r *http.Request) (string, bool) {
 	return token.UID, true
 }
 
+// isDetailedValidationError reports validation errors whose message tells the client
+// what to fix, so it is returned as-is with a 400
+func isDetailedValidationError(err error) bool {
+	for _, target := range []error{
+		services.ErrInvalidJobTrigger,
+		services.ErrInvalidJobSource,
+		services.ErrInvalidJobMode,
+		services.ErrInvalidWorkload,
+		services.ErrInvalidSelector,
+		services.ErrInvalidMultilineRule,
+		services.ErrInvalidContainerFilter,
+		services.ErrJobPreflight,
+		services.ErrInvalidScanOptions,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // POST /api/log-scan-jobs
 func HandleCreateLogScanJob(jobService services.JobService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -38,6 +60,10 @@ func HandleCreateLogScanJob(jobService services.JobService) http.HandlerFunc {
 		}
 		job, err := jobService.CreateLogScanJob(userID, req)
 		if err != nil {
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			if err == services.ErrInvalidJobRequest {
 				http.Error(w, "Missing namespace or invalid interval", http.StatusBadRequest)
 				return
@@ -54,6 +80,45 @@ func HandleCreateLogScanJob(jobService services.JobService) http.HandlerFunc {
 	}
 }
 
+// POST /api/log-scan-jobs/preview?analyze=true
+func HandlePreviewLogScanJob(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Jobs] PreviewLogScanJob called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Jobs] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req services.CreateJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Jobs] Invalid preview job request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		analyze := r.URL.Query().Get("analyze") == "true"
+		preview, err := jobService.PreviewLogScanJob(userID, req, analyze)
+		if err != nil {
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err == services.ErrInvalidJobRequest {
+				http.Error(w, "Missing namespace", http.StatusBadRequest)
+				return
+			}
+			logger.Logger.Error("[Jobs] Failed to preview job:", err)
+			http.Error(w, "Failed to preview job", http.StatusInternalServerError)
+			return
+		}
+		logger.Logger.Info("[Jobs] Preview matched", len(preview.Matches), "lines for user", userID)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(preview); err != nil {
+			logger.Logger.Error("[Jobs] Failed to encode preview response:", err)
+		}
+	}
+}
+
 // GET /api/log-scan-jobs
 func HandleListLogScanJobs(jobService services.JobService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +199,10 @@ func HandleUpdateLogScanJob(jobService services.JobService) http.HandlerFunc {
 		}
 		jobList, err := jobService.UpdateLogScanJob(userID, jobID, req)
 		if err != nil {
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
 			if err == services.ErrInvalidJobRequest {
 				http.Error(w, "Missing namespace or invalid interval", http.StatusBadRequest)
 				return
@@ -155,6 +224,54 @@ func HandleUpdateLogScanJob(jobService services.JobService) http.HandlerFunc {
 	}
 }
 
+// POST /api/log-scan-jobs/{id}/clone
+func HandleCloneLogScanJob(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Jobs] CloneLogScanJob called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Jobs] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) < 5 || parts[3] == "" {
+			http.Error(w, "Missing job ID", http.StatusBadRequest)
+			return
+		}
+		jobID := parts[3]
+		var req services.CloneJobRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				logger.Logger.Warn("[Jobs] Invalid clone job request:", err)
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+		job, err := jobService.CloneLogScanJob(userID, jobID, req)
+		if err != nil {
+			if err == services.ErrJobNotFound {
+				logger.Logger.Warn("[Jobs] Job not found for clone: jobID=", jobID)
+				http.Error(w, "Job not found", http.StatusNotFound)
+				return
+			}
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Logger.Error("[Jobs] Failed to clone job:", err)
+			http.Error(w, "Failed to clone job", http.StatusInternalServerError)
+			return
+		}
+		logger.Logger.Info("[Jobs] Job cloned for user", userID, "from", jobID, "to", job.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logger.Logger.Error("[Jobs] Failed to encode cloned job response:", err)
+		}
+	}
+}
+
 // GET /api/incidents/recent
 func HandleGetRecentIncidents(jobService services.JobService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -1,19 +1,52 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"backend/go-backend/logger"
+	"backend/go-backend/models"
 	"backend/go-backend/services"
 )
 
-// Refactored handler: injects K8sService
+// GET /k8s-clusters
+func HandleK8sClusters(k8sService services.K8sService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[K8s] HandleK8sClusters called from ", r.RemoteAddr)
+		clusters, err := k8sService.ListClusters()
+		if err != nil {
+			logger.Logger.Error("[K8s] Failed to list clusters: ", err)
+			http.Error(w, "Failed to list clusters", http.StatusInternalServerError)
+			return
+		}
+		if clusters == nil {
+			clusters = []models.ClusterInfo{}
+		}
+		logger.Logger.WithField("clusters", len(clusters)).Info("[K8s] Found clusters")
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string][]models.ClusterInfo{"clusters": clusters}); err != nil {
+			logger.Logger.Error("[K8s] Failed to encode clusters response:", err)
+		}
+	}
+}
+
+// GET /k8s-namespaces?cluster=...
 func HandleK8sNamespaces(k8sService services.K8sService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Logger.Info("[K8s] HandleK8sNamespaces called from ", r.RemoteAddr)
-		namespaces, err := k8sService.ListNamespaces()
+		namespaces, err := k8sService.ListNamespaces(r.URL.Query().Get("cluster"))
 		if err != nil {
+			if errors.Is(err, services.ErrUnknownCluster) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
 			logger.Logger.Error("[K8s] Failed to list namespaces: ", err)
 			http.Error(w, "Failed to list namespaces", http.StatusInternalServerError)
 			return
@@ -36,47 +69,180 @@ func HandleScanK8sLogs(k8sService services.K8sService) http.HandlerFunc {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
-		results, err := k8sService.ScanLogs(req)
+		results, scanErrs, err := k8sService.ScanLogs(req)
 		if err != nil {
 			if err == services.ErrInvalidScanRequest {
 				logger.Logger.Warn("[K8s] Missing namespace in scan request")
 				http.Error(w, "Missing namespace", http.StatusBadRequest)
 				return
 			}
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, services.ErrUnknownCluster) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
 			logger.Logger.Error("[K8s] Failed to scan logs: ", err)
 			http.Error(w, "Failed to scan logs", http.StatusInternalServerError)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
+		if scanErrs == nil {
+			scanErrs = []models.ScanError{}
+		}
 		if err := json.NewEncoder(w).Encode(map[string]interface{}{
 			"results": results,
+			"errors":  scanErrs,
 		}); err != nil {
 			logger.Logger.Error("[K8s] Failed to encode scan logs response:", err)
 		}
 	}
 }
 
-// GET /k8s-pods?cluster=...&namespace=...
+// GET /k8s-pods?cluster=...&namespace=...&label_selector=...&owner=...
+// owner is a workload as Kind/name (e.g. Deployment/web) or just its name.
 func HandleK8sPods(k8sService services.K8sService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Logger.Info("[K8s] HandleK8sPods called from ", r.RemoteAddr)
-		cluster := r.URL.Query().Get("cluster")
-		namespace := r.URL.Query().Get("namespace")
-		podNames, err := k8sService.ListPods(cluster, namespace)
+		query := r.URL.Query()
+		pods, err := k8sService.ListPods(services.ListPodsRequest{
+			Cluster:       query.Get("cluster"),
+			Namespace:     query.Get("namespace"),
+			LabelSelector: query.Get("label_selector"),
+			Owner:         query.Get("owner"),
+		})
 		if err != nil {
 			if err == services.ErrInvalidPodRequest {
 				logger.Logger.Warn("[K8s] Missing cluster or namespace in pods request")
 				http.Error(w, "Missing cluster or namespace", http.StatusBadRequest)
 				return
 			}
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, services.ErrUnknownCluster) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
 			logger.Logger.Error("[K8s] Failed to list pods: ", err)
 			http.Error(w, "Failed to list pods", http.StatusInternalServerError)
 			return
 		}
-		logger.Logger.WithField("pods", len(podNames)).Info("[K8s] Found pods")
+		logger.Logger.WithField("pods", len(pods)).Info("[K8s] Found pods")
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(map[string][]string{"pods": podNames}); err != nil {
+		if err := json.NewEncoder(w).Encode(map[string][]models.PodInfo{"pods": pods}); err != nil {
 			logger.Logger.Error("[K8s] Failed to encode pods response:", err)
 		}
 	}
 }
+
+// tailHeartbeat is how often an idle live tail sends an SSE comment to keep proxies from
+// closing the connection
+const tailHeartbeat = 15 * time.Second
+
+// GET /k8s-logs/tail?cluster=...&namespace=...&pod=...&label_selector=...&container=...&level=...&pattern=...&tail_lines=...
+// pod, level and pattern may be repeated. Streams Server-Sent Events: "line" events carry a
+// matched log line with its pod and container, "stream_end" and "stream_error" events report
+// a container whose log ended or failed.
+func HandleK8sLogTail(k8sService services.K8sService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[K8s] HandleK8sLogTail called from ", r.RemoteAddr)
+		query := r.URL.Query()
+		req := services.TailLogsRequest{
+			Cluster:        query.Get("cluster"),
+			Namespace:      query.Get("namespace"),
+			Pods:           query["pod"],
+			LabelSelector:  query.Get("label_selector"),
+			Container:      query.Get("container"),
+			LogLevels:      query["level"],
+			SearchPatterns: query["pattern"],
+		}
+		if v := query.Get("tail_lines"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Invalid tail_lines", http.StatusBadRequest)
+				return
+			}
+			req.TailLines = n
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+			return
+		}
+		tail, err := k8sService.TailLogs(req)
+		if err != nil {
+			if err == services.ErrInvalidScanRequest {
+				http.Error(w, "Missing namespace", http.StatusBadRequest)
+				return
+			}
+			if isDetailedValidationError(err) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, services.ErrUnknownCluster) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			logger.Logger.Error("[K8s] Failed to start log tail: ", err)
+			http.Error(w, "Failed to start log tail", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		flusher.Flush()
+
+		// Lines and heartbeats are written from different goroutines
+		var mu sync.Mutex
+		write := func(chunk string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := io.WriteString(w, chunk); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		// The heartbeat must stop before the handler returns and the response is finished
+		heartbeatDone := make(chan struct{})
+		go func() {
+			defer close(heartbeatDone)
+			ticker := time.NewTicker(tailHeartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := write(": ping\n\n"); err != nil {
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+		err = tail.Run(ctx, func(ev services.TailEvent) error {
+			var payload interface{} = ev
+			if ev.Kind == services.TailEventLine {
+				payload = ev.Match
+			}
+			b, err := json.Marshal(payload)
+			if err != nil {
+				return err
+			}
+			return write(fmt.Sprintf("event: %s\ndata: %s\n\n", ev.Kind, b))
+		})
+		cancel()
+		<-heartbeatDone
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Logger.Warn("[K8s] Log tail ended: ", err)
+		}
+		logger.Logger.Info("[K8s] Log tail closed for ", r.RemoteAddr)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/services"
+)
+
+// POST /api/silences
+func HandleCreateSilence(silenceService services.SilenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Silences] CreateSilence called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Silences] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req services.SilenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Silences] Invalid create silence request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		silence, err := silenceService.CreateSilence(userID, req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSilenceRequest) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Logger.Error("[Silences] Failed to create silence:", err)
+			http.Error(w, "Failed to create silence", http.StatusInternalServerError)
+			return
+		}
+		logger.Logger.Info("[Silences] Silence created for user", userID, ":", silence.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(silence); err != nil {
+			logger.Logger.Error("[Silences] Failed to encode silence response:", err)
+		}
+	}
+}
+
+// GET /api/silences
+func HandleListSilences(silenceService services.SilenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Silences] ListSilences called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Silences] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		silences, err := silenceService.ListSilences(userID)
+		if err != nil {
+			logger.Logger.Error("[Silences] Failed to list silences:", err)
+			http.Error(w, "Failed to list silences", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(silences); err != nil {
+			logger.Logger.Error("[Silences] Failed to encode silence list response:", err)
+		}
+	}
+}
+
+// PUT /api/silences/{id}
+func HandleUpdateSilence(silenceService services.SilenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Silences] UpdateSilence called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Silences] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) < 4 || parts[3] == "" {
+			http.Error(w, "Missing silence ID", http.StatusBadRequest)
+			return
+		}
+		silenceID := parts[3]
+		var req services.SilenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Silences] Invalid update silence request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		silence, err := silenceService.UpdateSilence(userID, silenceID, req)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidSilenceRequest) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err == services.ErrSilenceNotFound {
+				http.Error(w, "Silence not found", http.StatusNotFound)
+				return
+			}
+			logger.Logger.Error("[Silences] Failed to update silence:", err)
+			http.Error(w, "Failed to update silence", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(silence); err != nil {
+			logger.Logger.Error("[Silences] Failed to encode silence response:", err)
+		}
+	}
+}
+
+// DELETE /api/silences/{id}
+func HandleDeleteSilence(silenceService services.SilenceService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Silences] DeleteSilence called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Silences] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) < 4 || parts[3] == "" {
+			http.Error(w, "Missing silence ID", http.StatusBadRequest)
+			return
+		}
+		silenceID := parts[3]
+		if err := silenceService.DeleteSilence(userID, silenceID); err != nil {
+			if err == services.ErrSilenceNotFound {
+				http.Error(w, "Silence not found", http.StatusNotFound)
+				return
+			}
+			logger.Logger.Error("[Silences] Failed to delete silence:", err)
+			http.Error(w, "Failed to delete silence", http.StatusInternalServerError)
+			return
+		}
+		logger.Logger.Info("[Silences] Silence deleted for user", userID, "silenceID:", silenceID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/services"
+)
+
+// templateIDFromPath extracts {id} from /api/job-templates/{id}[/action]
+func templateIDFromPath(path string) (string, bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 4 || parts[3] == "" {
+		return "", false
+	}
+	return parts[3], true
+}
+
+// writeTemplateError maps template service errors to HTTP responses
+func writeTemplateError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTemplateRequest), isDetailedValidationError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == services.ErrInvalidJobRequest:
+		http.Error(w, "Missing namespace or invalid interval", http.StatusBadRequest)
+	case err == services.ErrTemplateNotFound:
+		http.Error(w, "Template not found", http.StatusNotFound)
+	default:
+		logger.Logger.Error("[Templates] Failed to "+action+":", err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+// POST /api/job-templates
+func HandleCreateTemplate(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] CreateTemplate called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req services.TemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Templates] Invalid create template request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		tmpl, err := templateService.CreateTemplate(userID, req)
+		if err != nil {
+			writeTemplateError(w, err, "create template")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(tmpl); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode template response:", err)
+		}
+	}
+}
+
+// GET /api/job-templates
+func HandleListTemplates(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] ListTemplates called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templates, err := templateService.ListTemplates(userID)
+		if err != nil {
+			writeTemplateError(w, err, "list templates")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(templates); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode template list response:", err)
+		}
+	}
+}
+
+// PUT /api/job-templates/{id}
+func HandleUpdateTemplate(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] UpdateTemplate called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templateID, ok := templateIDFromPath(r.URL.Path)
+		if !ok {
+			http.Error(w, "Missing template ID", http.StatusBadRequest)
+			return
+		}
+		var req services.TemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Templates] Invalid update template request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		tmpl, err := templateService.UpdateTemplate(userID, templateID, req)
+		if err != nil {
+			writeTemplateError(w, err, "update template")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(tmpl); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode template response:", err)
+		}
+	}
+}
+
+// DELETE /api/job-templates/{id}
+func HandleDeleteTemplate(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] DeleteTemplate called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templateID, ok := templateIDFromPath(r.URL.Path)
+		if !ok {
+			http.Error(w, "Missing template ID", http.StatusBadRequest)
+			return
+		}
+		if err := templateService.DeleteTemplate(userID, templateID); err != nil {
+			writeTemplateError(w, err, "delete template")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// POST /api/job-templates/{id}/instantiate
+func HandleInstantiateTemplate(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] InstantiateTemplate called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templateID, ok := templateIDFromPath(r.URL.Path)
+		if !ok {
+			http.Error(w, "Missing template ID", http.StatusBadRequest)
+			return
+		}
+		var req services.InstantiateTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Logger.Warn("[Templates] Invalid instantiate request:", err)
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		jobs, err := templateService.InstantiateTemplate(userID, templateID, req)
+		if err != nil {
+			writeTemplateError(w, err, "instantiate template")
+			return
+		}
+		logger.Logger.Info("[Templates] Created", len(jobs), "jobs from template", templateID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(jobs); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode instantiated jobs response:", err)
+		}
+	}
+}
+
+// GET /api/job-templates/{id}/preview-apply
+func HandlePreviewTemplateApply(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] PreviewTemplateApply called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templateID, ok := templateIDFromPath(r.URL.Path)
+		if !ok {
+			http.Error(w, "Missing template ID", http.StatusBadRequest)
+			return
+		}
+		changes, err := templateService.PreviewTemplateApply(userID, templateID)
+		if err != nil {
+			writeTemplateError(w, err, "preview template changes")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode template changes response:", err)
+		}
+	}
+}
+
+// POST /api/job-templates/{id}/apply
+func HandleApplyTemplate(templateService services.TemplateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Logger.Info("[Templates] ApplyTemplate called from", r.RemoteAddr)
+		userID, ok := getUserID(r)
+		if !ok {
+			logger.Logger.Warn("[Templates] Unauthorized request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		templateID, ok := templateIDFromPath(r.URL.Path)
+		if !ok {
+			http.Error(w, "Missing template ID", http.StatusBadRequest)
+			return
+		}
+		changes, err := templateService.ApplyTemplate(userID, templateID)
+		if err != nil {
+			writeTemplateError(w, err, "apply template")
+			return
+		}
+		logger.Logger.Info("[Templates] Applied template", templateID, "to", len(changes), "jobs")
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			logger.Logger.Error("[Templates] Failed to encode template changes response:", err)
+		}
+	}
+}
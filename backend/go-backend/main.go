@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"os"
 
@@ -71,16 +72,41 @@ func withCORS(handler http.HandlerFunc) http.HandlerFunc {
 
 func main() {
 	logger.Init()
-	utils.StartScheduler()
+	if err := utils.LoadIncidents(); err != nil {
+		logger.Logger.Error("Failed to load incidents: ", err)
+	}
+	if err := utils.LoadCursors(); err != nil {
+		logger.Logger.Error("Failed to load log cursors: ", err)
+	}
+	if err := utils.LoadSilences(); err != nil {
+		logger.Logger.Error("Failed to load silences: ", err)
+	}
+	if err := utils.LoadTemplates(); err != nil {
+		logger.Logger.Error("Failed to load job templates: ", err)
+	}
+	if err := utils.LoadClusterRegistry(); err != nil {
+		logger.Logger.Error("Failed to load cluster registry: ", err)
+	}
+	// Every component resolves clusters through the same registry-backed factory
+	clients := utils.RegistryClients{}
+	if utils.LeaderElectionEnabled() {
+		if err := utils.StartLeaderElectedScheduler(utils.LeaderElectionConfigFromEnv(), clients); err != nil {
+			logger.Logger.Fatalf("Failed to start leader election: %v", err)
+		}
+	} else {
+		utils.StartScheduler(clients)
+	}
 	logger.Logger.Info("[Main] Initializing backend...")
 	InitFirebase()
 
 	// Instantiate services
-	jobService := &services.DefaultJobService{}
-	k8sService := &services.DefaultK8sService{}
+	jobService := &services.DefaultJobService{Clients: clients}
+	k8sService := &services.DefaultK8sService{Clients: clients}
 	analyzeService := &services.DefaultAnalyzeService{}
 	metricsService := &services.DefaultMetricsService{}
 	healthService := &services.DefaultHealthService{}
+	silenceService := &services.DefaultSilenceService{}
+	templateService := &services.DefaultTemplateService{Clients: clients}
 	analyticsService := &handlers.DefaultAnalyticsService{}
 	configService := &handlers.DefaultConfigService{}
 
@@ -103,9 +129,11 @@ func main() {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	}))
+	http.HandleFunc("/k8s-clusters", withCORS(handlers.HandleK8sClusters(k8sService)))
 	http.HandleFunc("/k8s-namespaces", withCORS(handlers.HandleK8sNamespaces(k8sService)))
 	http.HandleFunc("/k8s-pods", withCORS(handlers.HandleK8sPods(k8sService)))
 	http.HandleFunc("/scan-k8s-logs", withCORS(handlers.HandleScanK8sLogs(k8sService)))
+	http.HandleFunc("/k8s-logs/tail", withCORS(handlers.HandleK8sLogTail(k8sService)))
 
 	// Protected endpoints (require Firebase Auth)
 	http.HandleFunc("/analyze", withCORS(FirebaseAuthMiddleware(handlers.HandleAnalyze(analyzeService))))
@@ -123,12 +151,28 @@ func main() {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})))
+	http.HandleFunc("/api/log-scan-jobs/preview", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.HandlePreviewLogScanJob(jobService)(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
 	http.HandleFunc("/api/log-scan-jobs/", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodDelete:
 			handlers.HandleDeleteLogScanJob(jobService)(w, r)
 		case http.MethodPut:
 			handlers.HandleUpdateLogScanJob(jobService)(w, r)
+		case http.MethodPost:
+			if !strings.HasSuffix(r.URL.Path, "/clone") {
+				http.Error(w, "Not found", http.StatusNotFound)
+				return
+			}
+			handlers.HandleCloneLogScanJob(jobService)(w, r)
 		case http.MethodOptions:
 			w.WriteHeader(http.StatusOK)
 		default:
@@ -146,6 +190,64 @@ func main() {
 		}
 	})))
 
+	// Silence and maintenance window management endpoints (all protected)
+	http.HandleFunc("/api/silences", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.HandleCreateSilence(silenceService)(w, r)
+		case http.MethodGet:
+			handlers.HandleListSilences(silenceService)(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+	http.HandleFunc("/api/silences/", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			handlers.HandleUpdateSilence(silenceService)(w, r)
+		case http.MethodDelete:
+			handlers.HandleDeleteSilence(silenceService)(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
+	// Job template endpoints (all protected)
+	http.HandleFunc("/api/job-templates", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.HandleCreateTemplate(templateService)(w, r)
+		case http.MethodGet:
+			handlers.HandleListTemplates(templateService)(w, r)
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+	http.HandleFunc("/api/job-templates/", withCORS(FirebaseAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodOptions:
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/instantiate"):
+			handlers.HandleInstantiateTemplate(templateService)(w, r)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/preview-apply"):
+			handlers.HandlePreviewTemplateApply(templateService)(w, r)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/apply"):
+			handlers.HandleApplyTemplate(templateService)(w, r)
+		case r.Method == http.MethodPut:
+			handlers.HandleUpdateTemplate(templateService)(w, r)
+		case r.Method == http.MethodDelete:
+			handlers.HandleDeleteTemplate(templateService)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})))
+
 	logger.Logger.Info("Go backend listening on :8080")
 	logger.Logger.Fatal(http.ListenAndServe(":8080", nil))
 }
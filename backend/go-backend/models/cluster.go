@@ -0,0 +1,24 @@
+package models
+
+// ClusterCredential registers a cluster by API server address and bearer token,
+// for clusters that are not reachable through a kubeconfig context
+type ClusterCredential struct {
+	Name                     string `json:"name"`
+	Server                   string `json:"server"`
+	Token                    string `json:"token"`
+	CertificateAuthorityData string `json:"certificate_authority_data,omitempty"` // base64-encoded PEM
+	InsecureSkipTLSVerify    bool   `json:"insecure_skip_tls_verify,omitempty"`
+}
+
+// ClusterInfo describes a registry entry and the result of its last connectivity check.
+// Cluster is the identifier accepted by every cluster-scoped API and by Job.Cluster.
+type ClusterInfo struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	Source    string `json:"source"` // in-cluster, kubeconfig or registered
+	Server    string `json:"server"`
+	Default   bool   `json:"default"`
+	Reachable bool   `json:"reachable"`
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -4,30 +4,136 @@ import "time"
 
 // Job represents a scheduled log scan job for a user
 type Job struct {
-	ID            string    `json:"id"`
-	UserID        string    `json:"user_id"`
-	Name          string    `json:"name"`
-	Cluster       string    `json:"cluster"`
-	Namespace     string    `json:"namespace"`
-	LogLevels     []string  `json:"log_levels"`
-	Interval      int       `json:"interval"` // seconds
+	ID        string   `json:"id"`
+	UserID    string   `json:"user_id"`
+	Team      string   `json:"team,omitempty"`
+	Name      string   `json:"name"`
+	Cluster   string   `json:"cluster"`
+	Namespace string   `json:"namespace"`
+	LogLevels []string `json:"log_levels"`
+	Interval  int      `json:"interval"` // seconds
+	// Mode is JobModeInterval (the default) or JobModeWatch
+	Mode          string    `json:"mode,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	LastRun       time.Time `json:"last_run"`
 	Microservices []string  `json:"microservices"`
 	Pods          []string  `json:"pods"`
+	// Selectors are resolved on every run; when Pods is also set, only those pods are kept
+	LabelSelector string `json:"label_selector,omitempty"`
+	FieldSelector string `json:"field_selector,omitempty"` // e.g. status.phase=Running
+	// Workloads, when set, keep only the pods the named workloads currently own
+	Workloads []WorkloadRef `json:"workloads,omitempty"`
+	// Containers narrows the containers of those pods that are scanned
+	Containers *ContainerFilter `json:"containers,omitempty"`
+	// Sources are the signals the job scans, any of JobSourceLogs, JobSourceEvents and
+	// JobSourceHealth; an empty list scans logs only
+	Sources []string          `json:"sources,omitempty"`
+	Health  *HealthThresholds `json:"health,omitempty"`
+	// Custom multi-line rules, tried before the built-in Java, Python and Go rules
+	Multiline []MultilineRule `json:"multiline,omitempty"`
+	// Set when the job was instantiated from a JobTemplate
+	TemplateID     string            `json:"template_id,omitempty"`
+	TemplateParams map[string]string `json:"template_params,omitempty"`
+	Triggers       []JobTrigger      `json:"triggers,omitempty"`
+	// Set only on runs enqueued by another job's trigger; never persisted
+	TriggeredBy  string   `json:"-"`
+	TriggerChain []string `json:"-"`
+}
+
+// Kinds of workload a job can target
+const (
+	WorkloadDeployment  = "Deployment"
+	WorkloadStatefulSet = "StatefulSet"
+	WorkloadDaemonSet   = "DaemonSet"
+	WorkloadCronJob     = "CronJob" // pods of its running Jobs and of recently failed ones
+)
+
+// WorkloadRef names a workload in the job's namespace
+type WorkloadRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// String returns the workload as "Kind/name", as recorded on incidents
+func (w WorkloadRef) String() string {
+	return w.Kind + "/" + w.Name
+}
+
+// Job modes. Interval jobs poll their sources every Interval seconds. Watch jobs follow
+// the logs of their pods as containers start and stop; their other sources keep polling.
+const (
+	JobModeInterval = "interval"
+	JobModeWatch    = "watch"
+)
+
+// Job sources
+const (
+	JobSourceLogs   = "logs"
+	JobSourceEvents = "events" // Warning events of the job's namespace
+	JobSourceHealth = "health" // pod and Deployment status, see HealthThresholds
+)
+
+// HealthThresholds tune the health source; zero values use the defaults noted per field
+type HealthThresholds struct {
+	RestartCount       int32 `json:"restart_count,omitempty"`       // restarts of a container (5)
+	PendingMinutes     int   `json:"pending_minutes,omitempty"`     // pod stuck Pending (10)
+	NotReadyMinutes    int   `json:"not_ready_minutes,omitempty"`   // running pod not Ready (5)
+	UnavailableMinutes int   `json:"unavailable_minutes,omitempty"` // Deployment with unavailable replicas (10)
+}
+
+// Trigger conditions
+const (
+	TriggerOnIncident = "on_incident"
+	TriggerOnFailure  = "on_failure"
+)
+
+// JobTrigger enqueues another of the user's jobs when a run of this job meets a condition
+type JobTrigger struct {
+	Condition   string        `json:"condition"` // TriggerOnIncident or TriggerOnFailure
+	TargetJobID string        `json:"target_job_id"`
+	MinSeverity string        `json:"min_severity,omitempty"` // on_incident: Low, Medium, High or Critical
+	Params      TriggerParams `json:"params"`
+}
+
+// TriggerParams override the target job's settings for the triggered run only
+type TriggerParams struct {
+	Namespace string   `json:"namespace,omitempty"`
+	LogLevels []string `json:"log_levels,omitempty"`
+	Pods      []string `json:"pods,omitempty"`
 }
 
 // Incident represents a detected incident from a log scan
 type Incident struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	JobID     string    `json:"job_id"`
-	Timestamp time.Time `json:"timestamp"`
-	LogLine   string    `json:"log_line"`
-	Analysis  string    `json:"analysis"`
-	RootCause string    `json:"root_cause"`
-	Knowledge string    `json:"knowledge"`
-	Action    string    `json:"action"`
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	JobID  string `json:"job_id"`
+	// Timestamp is when the log line was emitted; DetectedAt is when the scan found it
+	Timestamp  time.Time `json:"timestamp"`
+	DetectedAt time.Time `json:"detected_at,omitempty"`
+	LogLine    string    `json:"log_line"`
+	// Provenance of the log line
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Node      string `json:"node,omitempty"`
+	Level     string `json:"level,omitempty"`
+	MatchRule string `json:"match_rule,omitempty"`
+	// Source is JobSourceEvents or JobSourceHealth for incidents raised from a Kubernetes
+	// Event or a failed health check, whose Reason and object (e.g. "Pod/web-1") are
+	// recorded. Fingerprint groups repeats of the same problem into a single incident.
+	Source      string `json:"source,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Object      string `json:"object,omitempty"`
+	Workload    string `json:"workload,omitempty"` // owning workload, e.g. "Deployment/web"
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Set when the line came from a restarted container or its terminated previous instance
+	Previous  bool              `json:"previous,omitempty"`
+	Restart   *ContainerRestart `json:"restart,omitempty"`
+	Analysis  string            `json:"analysis"`
+	RootCause string            `json:"root_cause"`
+	Knowledge string            `json:"knowledge"`
+	Action    string            `json:"action"`
 	// New fields for UI
 	Title          string  `json:"title"`
 	Service        string  `json:"service"`
@@ -35,4 +141,15 @@ type Incident struct {
 	Status         string  `json:"status"`
 	Category       string  `json:"category"`
 	ResolutionTime float64 `json:"resolution_time"`
+	// SilenceID is set when a silence suppressed this incident (Status "Suppressed")
+	SilenceID string `json:"silence_id,omitempty"`
+	// TriggeredBy is the job whose trigger started the run that found this incident
+	TriggeredBy string `json:"triggered_by,omitempty"`
+}
+
+// LogCursor records how far a job has read one container's log, so the next
+// scan only fetches lines written after LastTimestamp.
+type LogCursor struct {
+	ContainerID   string    `json:"container_id"`
+	LastTimestamp time.Time `json:"last_timestamp"`
 }
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// LogMatch is a log line matched by a scan, with where and when it was emitted and why it matched
+type LogMatch struct {
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Node      string    `json:"node,omitempty"`
+	Workload  string    `json:"workload,omitempty"` // owning workload, e.g. "Deployment/web"
+	Timestamp time.Time `json:"timestamp"`          // as recorded by the container runtime
+	Level     string    `json:"level,omitempty"`
+	Rule      string    `json:"rule"` // the filter that matched, e.g. "level:ERROR" or "pattern:timeout"
+	Line      string    `json:"log"`
+	// Fields parsed from the line; Format is json, logfmt, klog, go, python, java or text
+	Format  string `json:"format"`
+	Message string `json:"message,omitempty"`
+	Logger  string `json:"logger,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Multiline names the rule that joined continuation lines into Line, e.g. "java"
+	Multiline string `json:"multiline,omitempty"`
+	// Previous is set for lines from the container's terminated previous instance;
+	// Restart is set whenever the container has restarted
+	Previous bool              `json:"previous,omitempty"`
+	Restart  *ContainerRestart `json:"restart,omitempty"`
+}
+
+// MultilineRule groups lines into one log event, e.g. an exception and its stack trace.
+// A line matching Start begins an event of this rule; following lines matching
+// Continuation are appended to it. Rules without Start extend whatever event precedes
+// the continuation line.
+type MultilineRule struct {
+	Name         string `json:"name"`
+	Start        string `json:"start,omitempty"`
+	Continuation string `json:"continuation"`
+	Level        string `json:"level,omitempty"` // level of events this rule starts when the first line names none
+	// Logged makes a start line continue the line before it when that line was logged at
+	// WARN or above, as loggers print an exception right after the message that logged it
+	Logged bool `json:"logged,omitempty"`
+}
+
+// ContainerFilter selects the containers of a pod to scan by name. Patterns are globs
+// such as "istio-*"; a container is scanned when it matches an Include pattern (or
+// Include is empty) and no Exclude pattern. Init and ephemeral containers are only
+// considered when enabled.
+type ContainerFilter struct {
+	Include   []string `json:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"`
+	Init      bool     `json:"init,omitempty"`
+	Ephemeral bool     `json:"ephemeral,omitempty"`
+}
+
+// ContainerRestart describes a restarted container and how its previous instance ended
+type ContainerRestart struct {
+	RestartCount      int32  `json:"restart_count"`
+	TerminationReason string `json:"termination_reason,omitempty"` // e.g. OOMKilled, Error
+	ExitCode          *int32 `json:"exit_code,omitempty"`
+}
+
+// ScanError reports a pod or container whose logs a scan could not read, or, with
+// Truncated set, could only read in part
+type ScanError struct {
+	Namespace string `json:"namespace,omitempty"` // set by scans across namespaces
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Error     string `json:"error"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
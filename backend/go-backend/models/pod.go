@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PodInfo summarizes a pod for choosing scan targets
+type PodInfo struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Phase     string `json:"phase"`
+	// Ready is the pod's Ready condition; ReadyContainers counts the ready ones of Containers
+	Ready           bool              `json:"ready"`
+	ReadyContainers int               `json:"ready_containers"`
+	Restarts        int32             `json:"restarts"` // summed over all containers
+	Containers      []ContainerInfo   `json:"containers"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// Owner is the workload that ultimately controls the pod, e.g. "Deployment/web"
+	Owner      string    `json:"owner,omitempty"`
+	Node       string    `json:"node,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+}
+
+// ContainerInfo describes one container of a pod and its current state
+type ContainerInfo struct {
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restart_count"`
+	State        string `json:"state"`            // running, waiting, terminated or unknown
+	Reason       string `json:"reason,omitempty"` // why the container is waiting or terminated
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Silence suppresses incident creation for matching log lines while it is active.
+// Every non-empty matcher must match; StartsAt/EndsAt bound when the silence applies.
+type Silence struct {
+	ID          string      `json:"id"`
+	UserID      string      `json:"user_id"`
+	Namespace   string      `json:"namespace,omitempty"`
+	PodPattern  string      `json:"pod_pattern,omitempty"` // glob, e.g. "api-*"
+	JobID       string      `json:"job_id,omitempty"`
+	Severity    string      `json:"severity,omitempty"`     // e.g. "High"
+	LinePattern string      `json:"line_pattern,omitempty"` // regular expression
+	StartsAt    time.Time   `json:"starts_at"`
+	EndsAt      time.Time   `json:"ends_at"` // zero means open-ended (recurring windows only)
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+	CreatedBy   string      `json:"created_by"`
+	Comment     string      `json:"comment"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Recurrence turns a silence into a recurring maintenance window, e.g. every
+// Tuesday and Thursday from 22:00 for 90 minutes in Europe/Berlin.
+type Recurrence struct {
+	Weekdays        []string `json:"weekdays"`   // "Mon".."Sun"; empty means every day
+	StartTime       string   `json:"start_time"` // "HH:MM"
+	DurationMinutes int      `json:"duration_minutes"`
+	Timezone        string   `json:"timezone"` // IANA name; empty means UTC
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// JobTemplate is a reusable scan job definition. Its string fields may contain
+// placeholders such as {{namespace}} or {{cluster}} that are filled in when the
+// template is instantiated into jobs; so may the label and field selectors and the
+// workload names. The remaining job settings are copied to the jobs as they are.
+type JobTemplate struct {
+	ID            string   `json:"id"`
+	UserID        string   `json:"user_id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	JobName       string   `json:"job_name"` // e.g. "{{namespace}} errors"
+	Team          string   `json:"team,omitempty"`
+	Cluster       string   `json:"cluster"`
+	Namespace     string   `json:"namespace"`
+	LogLevels     []string `json:"log_levels"`
+	Interval      int      `json:"interval"` // seconds
+	Pods          []string `json:"pods"`
+	Microservices []string `json:"microservices"`
+	Mode          string   `json:"mode,omitempty"`
+	LabelSelector string   `json:"label_selector,omitempty"`
+	FieldSelector string   `json:"field_selector,omitempty"`
+	Sources       []string `json:"sources,omitempty"`
+
+	Workloads  []WorkloadRef     `json:"workloads,omitempty"`
+	Containers *ContainerFilter  `json:"containers,omitempty"`
+	Health     *HealthThresholds `json:"health,omitempty"`
+
+	Multiline []MultilineRule `json:"multiline,omitempty"`
+	Triggers  []JobTrigger    `json:"triggers,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,5 +1,11 @@
 package services
 
+import (
+	"strconv"
+
+	"backend/go-backend/utils"
+)
+
 // HealthService abstracts health check operations for handlers
 type HealthService interface {
 	HealthStatus() map[string]string
@@ -8,7 +14,16 @@ type HealthService interface {
 // DefaultHealthService implements HealthService using current logic
 type DefaultHealthService struct{}
 
-// HealthStatus returns the health status of the service
+// HealthStatus returns the health status of the service, including scheduler leadership
 func (s DefaultHealthService) HealthStatus() map[string]string {
-	return map[string]string{"status": "ok"}
+	enabled, leading, leader := utils.LeadershipStatus()
+	status := map[string]string{
+		"status":          "ok",
+		"leader_election": strconv.FormatBool(enabled),
+		"leader":          strconv.FormatBool(leading),
+	}
+	if leader != "" {
+		status["leader_identity"] = leader
+	}
+	return status
 }
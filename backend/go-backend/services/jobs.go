@@ -4,10 +4,10 @@ import (
 	"backend/go-backend/models"
 	"backend/go-backend/utils"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"backend/go-backend/logger"
-
 	"github.com/google/uuid"
 )
 
@@ -17,32 +17,73 @@ type JobService interface {
 	UpdateLogScanJob(userID, jobID string, req UpdateJobRequest) ([]models.Job, error)
 	DeleteLogScanJob(userID, jobID string) error
 	GetRecentIncidents(userID string) ([]models.Incident, error)
+	PreviewLogScanJob(userID string, req CreateJobRequest, analyze bool) (utils.JobPreview, error)
+	CloneLogScanJob(userID, jobID string, req CloneJobRequest) (models.Job, error)
 }
 
-type DefaultJobService struct{}
+// DefaultJobService manages the users' jobs. When Clients is set, created, updated and
+// cloned jobs are checked against their cluster before they are saved, see preflight.
+type DefaultJobService struct {
+	Clients utils.ClientFactory
+}
 
 type CreateJobRequest struct {
 	Name          string   `json:"name"`
+	Team          string   `json:"team"`
 	Namespace     string   `json:"namespace"`
 	LogLevels     []string `json:"log_levels"`
 	Interval      int      `json:"interval"`
+	Mode          string   `json:"mode"`
 	Pods          []string `json:"pods"`
 	Cluster       string   `json:"cluster"`
 	Microservices []string `json:"microservices"`
+	LabelSelector string   `json:"label_selector"`
+	FieldSelector string   `json:"field_selector"`
+	Sources       []string `json:"sources"`
+
+	Workloads  []models.WorkloadRef     `json:"workloads"`
+	Containers *models.ContainerFilter  `json:"containers"`
+	Health     *models.HealthThresholds `json:"health"`
+
+	Multiline []models.MultilineRule `json:"multiline"`
+	Triggers  []models.JobTrigger    `json:"triggers"`
 }
 
 type UpdateJobRequest struct {
 	Name          string   `json:"name"`
+	Team          string   `json:"team"`
 	Namespace     string   `json:"namespace"`
 	LogLevels     []string `json:"log_levels"`
 	Interval      int      `json:"interval"`
+	Mode          string   `json:"mode"`
 	Microservices []string `json:"microservices"`
 	Pods          []string `json:"pods"`
 	Cluster       string   `json:"cluster"`
+	LabelSelector string   `json:"label_selector"`
+	FieldSelector string   `json:"field_selector"`
+	Sources       []string `json:"sources"`
+
+	Workloads  []models.WorkloadRef     `json:"workloads"`
+	Containers *models.ContainerFilter  `json:"containers"`
+	Health     *models.HealthThresholds `json:"health"`
+
+	Multiline []models.MultilineRule `json:"multiline"`
+	Triggers  []models.JobTrigger    `json:"triggers"`
+}
+
+// CloneJobRequest optionally overrides fields of the cloned job
+type CloneJobRequest struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
 }
 
 var ErrInvalidJobRequest = errors.New("invalid job request")
 var ErrJobNotFound = errors.New("job not found")
+var ErrInvalidJobTrigger = errors.New("invalid job trigger")
+var ErrInvalidJobSource = errors.New("invalid job source")
+var ErrInvalidJobMode = errors.New("invalid job mode")
+var ErrInvalidWorkload = errors.New("invalid job workload")
 
 func (s *DefaultJobService) ListLogScanJobs(userID string) ([]models.Job, error) {
 	jobs := utils.GetJobs(userID)
@@ -52,56 +93,70 @@ func (s *DefaultJobService) ListLogScanJobs(userID string) ([]models.Job, error)
 	return jobs, nil
 }
 
+// UpdateLogScanJob checks the updated job, which may call its cluster, before taking the
+// jobs lock, then applies the request to the job as currently stored so edits made
+// meanwhile by the scheduler or a template apply are not overwritten
 func (s *DefaultJobService) UpdateLogScanJob(userID, jobID string, req UpdateJobRequest) ([]models.Job, error) {
-	if req.Namespace == "" || req.Interval <= 0 {
-		return nil, ErrInvalidJobRequest
-	}
-	jobs := utils.GetJobs(userID)
-	updated := false
-	for i, job := range jobs {
-		if job.ID == jobID {
-			jobs[i].Name = req.Name
-			jobs[i].Namespace = req.Namespace
-			jobs[i].LogLevels = req.LogLevels
-			jobs[i].Interval = req.Interval
-			jobs[i].Microservices = req.Microservices
-			jobs[i].Pods = req.Pods
-			jobs[i].Cluster = req.Cluster
-			updated = true
-			break
-		}
-	}
-	if !updated {
+	stored := utils.GetJobs(userID)
+	idx := findJobIndex(stored, jobID)
+	if idx == -1 {
 		return nil, ErrJobNotFound
 	}
-	utils.SetJobs(userID, jobs)
-	go func() {
-		if err := utils.SaveJobs(); err != nil {
-			logger.Logger.Error("Error saving jobs in UpdateLogScanJob goroutine:", err)
+	job := stored[idx]
+	applyUpdateRequest(&job, req)
+	if err := checkJob(s.Clients, userID, jobID, job); err != nil {
+		return nil, err
+	}
+	var updated []models.Job
+	err := utils.UpdateJobs(userID, func(jobs []models.Job) ([]models.Job, error) {
+		idx := findJobIndex(jobs, jobID)
+		if idx == -1 {
+			return nil, ErrJobNotFound
 		}
-	}()
-	return jobs, nil
+		applyUpdateRequest(&jobs[idx], req)
+		updated = jobs
+		return jobs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// applyUpdateRequest copies the editable fields of an update request onto a job
+func applyUpdateRequest(job *models.Job, req UpdateJobRequest) {
+	job.Name = req.Name
+	job.Team = req.Team
+	job.Namespace = req.Namespace
+	job.LogLevels = req.LogLevels
+	job.Interval = req.Interval
+	job.Mode = req.Mode
+	job.Microservices = req.Microservices
+	job.Pods = req.Pods
+	job.Cluster = req.Cluster
+	job.LabelSelector = req.LabelSelector
+	job.FieldSelector = req.FieldSelector
+	job.Workloads = req.Workloads
+	job.Containers = req.Containers
+	job.Sources = req.Sources
+	job.Health = req.Health
+	job.Multiline = req.Multiline
+	job.Triggers = req.Triggers
 }
 
 func (s *DefaultJobService) DeleteLogScanJob(userID, jobID string) error {
-	jobs := utils.GetJobs(userID)
-	idx := -1
-	for i, job := range jobs {
-		if job.ID == jobID {
-			idx = i
-			break
+	err := utils.UpdateJobs(userID, func(jobs []models.Job) ([]models.Job, error) {
+		idx := findJobIndex(jobs, jobID)
+		if idx == -1 {
+			return nil, ErrJobNotFound
 		}
+		return append(jobs[:idx], jobs[idx+1:]...), nil
+	})
+	if err != nil {
+		return err
 	}
-	if idx == -1 {
-		return ErrJobNotFound
-	}
-	jobs = append(jobs[:idx], jobs[idx+1:]...)
-	utils.SetJobs(userID, jobs)
-	go func() {
-		if err := utils.SaveJobs(); err != nil {
-			logger.Logger.Error("Error saving jobs in DeleteLogScanJob goroutine:", err)
-		}
-	}()
+	utils.DeleteJobCursors(jobID)
+	utils.ForgetJobFindings(jobID)
 	return nil
 }
 
@@ -114,9 +169,55 @@ func (s *DefaultJobService) GetRecentIncidents(userID string) ([]models.Incident
 }
 
 func (s *DefaultJobService) CreateLogScanJob(userID string, req CreateJobRequest) (models.Job, error) {
-	if req.Namespace == "" || req.Interval <= 0 {
-		return models.Job{}, ErrInvalidJobRequest
+	job := jobFromRequest(userID, req)
+	if err := checkJob(s.Clients, userID, "", job); err != nil {
+		return models.Job{}, err
+	}
+	if err := utils.AddJob(userID, job); err != nil {
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// checkJob validates a job that is about to be saved and, when clients is set, checks
+// it against its cluster. Every path that saves a job goes through it. jobID is empty
+// for a new job.
+func checkJob(clients utils.ClientFactory, userID, jobID string, job models.Job) error {
+	if err := validateJob(userID, jobID, job); err != nil {
+		return err
+	}
+	return preflight(clients, job)
+}
+
+// validateJob checks a job definition without contacting its cluster
+func validateJob(userID, jobID string, job models.Job) error {
+	if job.Namespace == "" || job.Interval <= 0 {
+		return ErrInvalidJobRequest
+	}
+	if err := validateSelectors(job.LabelSelector, job.FieldSelector); err != nil {
+		return err
 	}
+	if err := validateMultiline(job.Multiline); err != nil {
+		return err
+	}
+	if err := validateWorkloads(job.Workloads); err != nil {
+		return err
+	}
+	if err := validateContainers(job.Containers); err != nil {
+		return err
+	}
+	if err := validateSources(job.Sources, job.Health); err != nil {
+		return err
+	}
+	if err := validateMode(job.Mode); err != nil {
+		return err
+	}
+	return validateTriggers(userID, jobID, job.Triggers)
+}
+
+// jobFromRequest builds a new, not yet stored job from a create request, selecting
+// every microservice when none are given
+func jobFromRequest(userID string, req CreateJobRequest) models.Job {
 	if len(req.Microservices) == 0 {
 		req.Microservices = []string{
 			"log_analyzer",
@@ -125,21 +226,202 @@ func (s *DefaultJobService) CreateLogScanJob(userID string, req CreateJobRequest
 			"action_recommender",
 		}
 	}
-	job := models.Job{
+	return models.Job{
 		ID:            uuid.New().String(),
 		UserID:        userID,
+		Team:          req.Team,
 		Name:          req.Name,
 		Cluster:       req.Cluster,
 		Namespace:     req.Namespace,
 		LogLevels:     req.LogLevels,
 		Interval:      req.Interval,
+		Mode:          req.Mode,
 		Pods:          req.Pods,
+		LabelSelector: req.LabelSelector,
+		FieldSelector: req.FieldSelector,
+		Workloads:     req.Workloads,
+		Containers:    req.Containers,
+		Sources:       req.Sources,
+		Health:        req.Health,
+		Multiline:     req.Multiline,
 		CreatedAt:     time.Now(),
 		LastRun:       time.Now().Add(-time.Duration(req.Interval) * time.Second),
 		Microservices: req.Microservices,
+		Triggers:      req.Triggers,
 	}
-	if err := utils.AddJob(userID, job); err != nil {
+}
+
+// validateMode checks the job mode; empty means interval
+func validateMode(mode string) error {
+	switch mode {
+	case "", models.JobModeInterval, models.JobModeWatch:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown mode %q, expected %q or %q", ErrInvalidJobMode, mode, models.JobModeInterval, models.JobModeWatch)
+}
+
+// validateWorkloads checks that every workload has a supported kind and a name and is
+// listed once
+func validateWorkloads(workloads []models.WorkloadRef) error {
+	seen := make(map[string]bool)
+	for _, w := range workloads {
+		switch w.Kind {
+		case models.WorkloadDeployment, models.WorkloadStatefulSet, models.WorkloadDaemonSet, models.WorkloadCronJob:
+		default:
+			return fmt.Errorf("%w: unknown kind %q, expected %q, %q, %q or %q", ErrInvalidWorkload, w.Kind,
+				models.WorkloadDeployment, models.WorkloadStatefulSet, models.WorkloadDaemonSet, models.WorkloadCronJob)
+		}
+		if w.Name == "" {
+			return fmt.Errorf("%w: %s needs a name", ErrInvalidWorkload, w.Kind)
+		}
+		if seen[w.String()] {
+			return fmt.Errorf("%w: %s listed twice", ErrInvalidWorkload, w)
+		}
+		seen[w.String()] = true
+	}
+	return nil
+}
+
+// validateSources checks that every job source is known and listed once, and that
+// health thresholds are not negative
+func validateSources(sources []string, health *models.HealthThresholds) error {
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		switch source {
+		case models.JobSourceLogs, models.JobSourceEvents, models.JobSourceHealth:
+		default:
+			return fmt.Errorf("%w: unknown source %q, expected %q, %q or %q", ErrInvalidJobSource, source,
+				models.JobSourceLogs, models.JobSourceEvents, models.JobSourceHealth)
+		}
+		if seen[source] {
+			return fmt.Errorf("%w: source %q listed twice", ErrInvalidJobSource, source)
+		}
+		seen[source] = true
+	}
+	if health != nil && (health.RestartCount < 0 || health.PendingMinutes < 0 || health.NotReadyMinutes < 0 || health.UnavailableMinutes < 0) {
+		return fmt.Errorf("%w: health thresholds must not be negative", ErrInvalidJobSource)
+	}
+	return nil
+}
+
+// validateTriggers checks that every trigger has a known condition and targets another
+// existing job of the same user. jobID is empty for a job that is being created.
+func validateTriggers(userID, jobID string, triggers []models.JobTrigger) error {
+	if len(triggers) == 0 {
+		return nil
+	}
+	existing := make(map[string]bool)
+	for _, job := range utils.GetJobs(userID) {
+		existing[job.ID] = true
+	}
+	for _, t := range triggers {
+		switch t.Condition {
+		case models.TriggerOnIncident, models.TriggerOnFailure:
+		default:
+			return fmt.Errorf("%w: unknown condition %q, expected %q or %q", ErrInvalidJobTrigger, t.Condition, models.TriggerOnIncident, models.TriggerOnFailure)
+		}
+		if t.TargetJobID == "" || !existing[t.TargetJobID] {
+			return fmt.Errorf("%w: target job %q not found", ErrInvalidJobTrigger, t.TargetJobID)
+		}
+		if t.TargetJobID == jobID {
+			return fmt.Errorf("%w: a job cannot trigger itself", ErrInvalidJobTrigger)
+		}
+		switch strings.ToLower(t.MinSeverity) {
+		case "", "low", "medium", "high", "critical":
+		default:
+			return fmt.Errorf("%w: invalid min_severity %q", ErrInvalidJobTrigger, t.MinSeverity)
+		}
+	}
+	return nil
+}
+
+// PreviewLogScanJob runs a job definition once without saving it, persisting incidents
+// or touching any existing job
+func (s *DefaultJobService) PreviewLogScanJob(userID string, req CreateJobRequest, analyze bool) (utils.JobPreview, error) {
+	if req.Namespace == "" {
+		return utils.JobPreview{}, ErrInvalidJobRequest
+	}
+	if err := validateSelectors(req.LabelSelector, req.FieldSelector); err != nil {
+		return utils.JobPreview{}, err
+	}
+	if err := validateMultiline(req.Multiline); err != nil {
+		return utils.JobPreview{}, err
+	}
+	if err := validateWorkloads(req.Workloads); err != nil {
+		return utils.JobPreview{}, err
+	}
+	if err := validateContainers(req.Containers); err != nil {
+		return utils.JobPreview{}, err
+	}
+	return utils.PreviewLogScanJob(s.Clients, jobFromRequest(userID, req), analyze)
+}
+
+// CloneLogScanJob copies an existing job under a new ID, applying any overrides.
+// The clone keeps its template link; overridden namespace/cluster values are also
+// recorded in its template parameters so re-applying the template keeps them.
+func (s *DefaultJobService) CloneLogScanJob(userID, jobID string, req CloneJobRequest) (models.Job, error) {
+	var source *models.Job
+	for _, job := range utils.GetJobs(userID) {
+		if job.ID == jobID {
+			source = &job
+			break
+		}
+	}
+	if source == nil {
+		return models.Job{}, ErrJobNotFound
+	}
+	clone := *source
+	clone.ID = uuid.New().String()
+	clone.CreatedAt = time.Now()
+	clone.LastRun = time.Now().Add(-time.Duration(clone.Interval) * time.Second)
+	clone.LogLevels = append([]string(nil), source.LogLevels...)
+	clone.Pods = append([]string(nil), source.Pods...)
+	clone.Microservices = append([]string(nil), source.Microservices...)
+	clone.Workloads = append([]models.WorkloadRef(nil), source.Workloads...)
+	if source.Containers != nil {
+		containers := models.ContainerFilter{
+			Include:   append([]string(nil), source.Containers.Include...),
+			Exclude:   append([]string(nil), source.Containers.Exclude...),
+			Init:      source.Containers.Init,
+			Ephemeral: source.Containers.Ephemeral,
+		}
+		clone.Containers = &containers
+	}
+	clone.Sources = append([]string(nil), source.Sources...)
+	if source.Health != nil {
+		health := *source.Health
+		clone.Health = &health
+	}
+	clone.Multiline = append([]models.MultilineRule(nil), source.Multiline...)
+	clone.Triggers = append([]models.JobTrigger(nil), source.Triggers...)
+	if source.TemplateParams != nil {
+		clone.TemplateParams = make(map[string]string, len(source.TemplateParams))
+		for k, v := range source.TemplateParams {
+			clone.TemplateParams[k] = v
+		}
+	}
+	if req.Name != "" {
+		clone.Name = req.Name
+	} else {
+		clone.Name = source.Name + " (copy)"
+	}
+	if req.Namespace != "" {
+		clone.Namespace = req.Namespace
+		if _, ok := clone.TemplateParams["namespace"]; ok {
+			clone.TemplateParams["namespace"] = req.Namespace
+		}
+	}
+	if req.Cluster != "" {
+		clone.Cluster = req.Cluster
+		if _, ok := clone.TemplateParams["cluster"]; ok {
+			clone.TemplateParams["cluster"] = req.Cluster
+		}
+	}
+	if err := checkJob(s.Clients, userID, clone.ID, clone); err != nil {
 		return models.Job{}, err
 	}
-	return job, nil
+	if err := utils.AddJob(userID, clone); err != nil {
+		return models.Job{}, err
+	}
+	return clone, nil
 }
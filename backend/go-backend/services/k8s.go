@@ -3,53 +3,124 @@ package services
 import (
 	"context"
 	"errors"
-	"io"
-	"os"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+// K8sService exposes cluster-scoped Kubernetes operations. Every cluster argument names
+// an entry of the utils cluster registry; an empty name selects the default cluster.
 type K8sService interface {
-	ListNamespaces() ([]string, error)
-	ListPods(cluster, namespace string) ([]string, error)
-	ScanLogs(req ScanLogsRequest) ([]map[string]interface{}, error)
+	ListClusters() ([]models.ClusterInfo, error)
+	ListNamespaces(cluster string) ([]string, error)
+	ListPods(req ListPodsRequest) ([]models.PodInfo, error)
+	ScanLogs(req ScanLogsRequest) ([]models.LogMatch, []models.ScanError, error)
+	TailLogs(req TailLogsRequest) (*LogTail, error)
 }
 
-type DefaultK8sService struct{}
+// DefaultK8sService reads the clusters through Clients, the cluster registry when nil
+type DefaultK8sService struct {
+	Clients utils.ClientFactory
+}
+
+func (s *DefaultK8sService) clients() utils.ClientFactory {
+	return utils.ClientsOrRegistry(s.Clients)
+}
 
 type ScanLogsRequest struct {
-	ClusterConfig    map[string]interface{} `json:"cluster_config"`
-	Namespaces       []string               `json:"namespaces"`
-	PodLabels        map[string]string      `json:"pod_labels"`
-	TimeRangeMinutes int                    `json:"time_range_minutes"`
-	LogLevels        []string               `json:"log_levels"`
-	SearchPatterns   []string               `json:"search_patterns"`
-	MaxLinesPerPod   int                    `json:"max_lines_per_pod"`
+	Cluster          string                  `json:"cluster"`
+	ClusterConfig    map[string]interface{}  `json:"cluster_config"`
+	Namespaces       []string                `json:"namespaces"`
+	PodLabels        map[string]string       `json:"pod_labels"`
+	LabelSelector    string                  `json:"label_selector"`
+	FieldSelector    string                  `json:"field_selector"`
+	TimeRangeMinutes int                     `json:"time_range_minutes"`
+	LogLevels        []string                `json:"log_levels"`
+	SearchPatterns   []string                `json:"search_patterns"`
+	MaxLinesPerPod   int                     `json:"max_lines_per_pod"`
+	Multiline        []models.MultilineRule  `json:"multiline"`
+	Containers       *models.ContainerFilter `json:"containers"`
 }
 
 var ErrInvalidPodRequest = errors.New("missing cluster or namespace")
 var ErrInvalidScanRequest = errors.New("missing namespace in scan request")
 
-func (s *DefaultK8sService) ListNamespaces() ([]string, error) {
-	var config *rest.Config
-	var err error
-	config, err = rest.InClusterConfig()
-	if err != nil {
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			kubeconfig = os.ExpandEnv("$HOME/.kube/config")
-		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, err
+// ErrInvalidScanOptions is returned when a scan exceeds a limit or has an invalid search pattern
+var ErrInvalidScanOptions = errors.New("invalid scan request")
+
+// ErrInvalidSelector is returned when a label or field selector does not parse
+var ErrInvalidSelector = errors.New("invalid pod selector")
+
+// ErrInvalidMultilineRule is returned when a custom multi-line rule is incomplete or does not compile
+var ErrInvalidMultilineRule = errors.New("invalid multiline rule")
+
+// ErrInvalidContainerFilter is returned when a container include or exclude pattern is not a valid glob
+var ErrInvalidContainerFilter = errors.New("invalid container filter")
+
+// ErrUnknownCluster is returned when a request names a cluster that is not registered
+var ErrUnknownCluster = utils.ErrUnknownCluster
+
+func validateSelectors(labelSelector, fieldSelector string) error {
+	if err := utils.ValidatePodSelectors(labelSelector, fieldSelector); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+	}
+	return nil
+}
+
+func validateMultiline(rules []models.MultilineRule) error {
+	if err := utils.ValidateMultilineRules(rules); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMultilineRule, err)
+	}
+	return nil
+}
+
+func validateContainers(filter *models.ContainerFilter) error {
+	if err := utils.ValidateContainerFilter(filter); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContainerFilter, err)
+	}
+	return nil
+}
+
+func (s *DefaultK8sService) ListClusters() ([]models.ClusterInfo, error) {
+	return utils.ListClusters()
+}
+
+// labelSelector combines pod_labels (exact matches) with the label_selector expression
+func (req ScanLogsRequest) labelSelector() string {
+	var parts []string
+	if len(req.PodLabels) > 0 {
+		parts = append(parts, labels.SelectorFromSet(req.PodLabels).String())
+	}
+	if req.LabelSelector != "" {
+		parts = append(parts, req.LabelSelector)
+	}
+	return strings.Join(parts, ",")
+}
+
+// clusterName returns the cluster a scan targets: the cluster field, or the name or
+// context sent in cluster_config by older clients
+func (req ScanLogsRequest) clusterName() string {
+	if req.Cluster != "" {
+		return req.Cluster
+	}
+	for _, key := range []string{"name", "context"} {
+		if v, ok := req.ClusterConfig[key].(string); ok && v != "" {
+			return v
 		}
 	}
-	clientset, err := kubernetes.NewForConfig(config)
+	return ""
+}
+
+func (s *DefaultK8sService) ListNamespaces(cluster string) ([]string, error) {
+	_, clientset, err := s.clients().Client(cluster)
 	if err != nil {
 		return nil, err
 	}
@@ -64,83 +135,127 @@ func (s *DefaultK8sService) ListNamespaces() ([]string, error) {
 	return namespaces, nil
 }
 
-func (s *DefaultK8sService) ListPods(cluster, namespace string) ([]string, error) {
-	if cluster == "" || namespace == "" {
-		return nil, ErrInvalidPodRequest
+// ScanLogs reads recent logs of the matching pods in every requested namespace and returns
+// the lines whose parsed level is one of the log levels and whose parsed fields match one
+// of the search patterns (either filter is skipped when empty). Each pod contributes at most
+// MaxLinesPerPod matches. Without a time range only the last MaxLinesPerPod lines of each
+// container are read; with one, the range is read from its start, up to
+// utils.DefaultLogScanLimitBytes. Containers whose logs cannot be read are returned as scan
+// errors, as are logs cut short by the limit, marked as truncated.
+func (s *DefaultK8sService) ScanLogs(req ScanLogsRequest) ([]models.LogMatch, []models.ScanError, error) {
+	if len(req.Namespaces) == 0 {
+		return nil, nil, ErrInvalidScanRequest
 	}
-	var config *rest.Config
-	var err error
-	config, err = rest.InClusterConfig()
-	if err != nil {
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			kubeconfig = os.ExpandEnv("$HOME/.kube/config")
-		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, err
+	for _, ns := range req.Namespaces {
+		if ns == "" {
+			return nil, nil, ErrInvalidScanRequest
 		}
 	}
-	clientset, err := kubernetes.NewForConfig(config)
+	patterns, err := validateScanOptions(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	assembler, err := utils.NewMultilineAssembler(req.Multiline)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidMultilineRule, err)
 	}
-	var podNames []string
-	for _, pod := range pods.Items {
-		podNames = append(podNames, pod.Name)
+	labelSelector := req.labelSelector()
+	if err := validateSelectors(labelSelector, req.FieldSelector); err != nil {
+		return nil, nil, err
 	}
-	return podNames, nil
-}
-
-func (s *DefaultK8sService) ScanLogs(req ScanLogsRequest) ([]map[string]interface{}, error) {
-	if len(req.Namespaces) == 0 || req.Namespaces[0] == "" {
-		return nil, ErrInvalidScanRequest
+	if err := validateContainers(req.Containers); err != nil {
+		return nil, nil, err
 	}
-	namespace := req.Namespaces[0]
-	var config *rest.Config
-	var err error
-	config, err = rest.InClusterConfig()
+	cluster, clientset, err := s.clients().Client(req.clusterName())
 	if err != nil {
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			kubeconfig = os.ExpandEnv("$HOME/.kube/config")
-		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, err
-		}
+		return nil, nil, err
 	}
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
+	maxLines := req.MaxLinesPerPod
+	if maxLines == 0 {
+		maxLines = DefaultScanLinesPerPod
 	}
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return nil, err
+	logOpts := corev1.PodLogOptions{TailLines: int64Ptr(int64(maxLines)), Timestamps: true}
+	if req.TimeRangeMinutes > 0 {
+		// Tailing would hide everything before the range's last lines
+		logOpts.TailLines = nil
+		logOpts.SinceSeconds = int64Ptr(int64(req.TimeRangeMinutes) * 60)
+		logOpts.LimitBytes = int64Ptr(utils.DefaultLogScanLimitBytes)
 	}
-	logLevels := make(map[string]bool)
-	for _, lvl := range req.LogLevels {
-		logLevels[strings.ToUpper(lvl)] = true
-	}
-	var logs []string
-	for _, pod := range pods.Items {
-		for _, c := range pod.Spec.Containers {
-			logOpts := &corev1.PodLogOptions{Container: c.Name, TailLines: int64Ptr(100)}
-			reqLog := clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, logOpts)
-			stream, err := reqLog.Stream(context.Background())
-			if err != nil {
-				continue
-			}
-			b, err := io.ReadAll(stream)
-			if err == nil {
-				for _, line := range strings.Split(string(b), "\n") {
-					for lvl := range logLevels {
-						if strings.Contains(line, lvl) {
-							logs = append(logs, line)
+	logLevels := utils.LogLevelSet(req.LogLevels)
+
+	results := []models.LogMatch{}
+	var scanErrs []models.ScanError
+	for _, namespace := range req.Namespaces {
+		pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+			LabelSelector: labelSelector,
+			FieldSelector: req.FieldSelector,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, pod := range pods.Items {
+			podLines := 0
+			for _, container := range utils.ContainerNames(&pod, req.Containers) {
+				if podLines >= maxLines {
+					break
+				}
+				// A restarted container's crash is logged by its terminated previous instance
+				restart, _ := utils.ContainerRestartFor(&pod, container)
+				instances := []bool{false}
+				if restart != nil {
+					instances = []bool{true, false}
+				}
+				for _, previous := range instances {
+					if podLines >= maxLines {
+						break
+					}
+					opts := logOpts
+					opts.Container = container
+					opts.Previous = previous
+					timestamps, lines, truncated, err := utils.ReadLogLines(clientset, namespace, pod.Name, &opts)
+					if err != nil {
+						what := "reading container log"
+						if previous {
+							what = "reading previous container log"
+						}
+						scanErrs = append(scanErrs, models.ScanError{
+							Namespace: namespace, Pod: pod.Name, Container: container, Error: what + ": " + err.Error(),
+						})
+						continue
+					}
+					if truncated {
+						// The range is read from its start, so the newest lines are the ones missing
+						scanErrs = append(scanErrs, truncationError(namespace, pod.Name, container, previous, *opts.LimitBytes, timestamps))
+					}
+					for _, event := range assembler.Assemble(timestamps, lines) {
+						if len(event.Lines) == 1 && event.Lines[0] == "" {
+							continue
+						}
+						parsed := event.Parse()
+						rule, matched := scanLineMatches(parsed, logLevels, patterns)
+						if !matched {
+							continue
+						}
+						results = append(results, models.LogMatch{
+							Cluster:   cluster,
+							Namespace: namespace,
+							Pod:       pod.Name,
+							Container: container,
+							Node:      pod.Spec.NodeName,
+							Timestamp: event.Timestamp,
+							Level:     parsed.Level,
+							Rule:      rule,
+							Line:      event.Text(),
+							Format:    parsed.Format,
+							Message:   parsed.Message,
+							Logger:    parsed.Logger,
+							Error:     parsed.Error,
+							Multiline: event.Rule,
+							Previous:  previous,
+							Restart:   restart,
+						})
+						podLines++
+						if podLines >= maxLines {
 							break
 						}
 					}
@@ -148,17 +263,99 @@ func (s *DefaultK8sService) ScanLogs(req ScanLogsRequest) ([]map[string]interfac
 			}
 		}
 	}
-	if len(logs) == 0 {
-		return []map[string]interface{}{}, nil
+	return results, scanErrs, nil
+}
+
+// truncationError reports a container log whose time range exceeded the byte limit
+func truncationError(namespace, pod, container string, previous bool, limit int64, timestamps []time.Time) models.ScanError {
+	what := "container log"
+	if previous {
+		what = "previous container log"
+	}
+	msg := fmt.Sprintf("%s exceeds %d bytes in the time range", what, limit)
+	if len(timestamps) > 0 {
+		msg += "; lines after " + timestamps[len(timestamps)-1].UTC().Format(time.RFC3339) + " were not read"
+	}
+	return models.ScanError{Namespace: namespace, Pod: pod, Container: container, Error: msg, Truncated: true}
+}
+
+// Limits on ad-hoc scans, matching the k8s_log_scanner service
+const (
+	DefaultScanLinesPerPod = 100
+	MaxScanNamespaces      = 10
+	MaxScanLinesPerPod     = 2000
+	MaxScanTimeRange       = 1440 // minutes
+	MaxSearchPatterns      = 10
+	MaxSearchPatternLength = 100
+)
+
+// validateScanOptions checks the scan limits and compiles the search patterns,
+// which are matched case-insensitively
+func validateScanOptions(req ScanLogsRequest) ([]*regexp.Regexp, error) {
+	switch {
+	case len(req.Namespaces) > MaxScanNamespaces:
+		return nil, fmt.Errorf("%w: too many namespaces (max %d)", ErrInvalidScanOptions, MaxScanNamespaces)
+	case req.MaxLinesPerPod < 0 || req.MaxLinesPerPod > MaxScanLinesPerPod:
+		return nil, fmt.Errorf("%w: max_lines_per_pod must be between 0 and %d", ErrInvalidScanOptions, MaxScanLinesPerPod)
+	case req.TimeRangeMinutes < 0 || req.TimeRangeMinutes > MaxScanTimeRange:
+		return nil, fmt.Errorf("%w: time_range_minutes must be between 0 and %d", ErrInvalidScanOptions, MaxScanTimeRange)
+	}
+	return compileSearchPatterns(req.SearchPatterns)
+}
+
+// compileSearchPatterns checks the search pattern limits and compiles the patterns
+// for case-insensitive matching
+func compileSearchPatterns(searchPatterns []string) ([]*regexp.Regexp, error) {
+	if len(searchPatterns) > MaxSearchPatterns {
+		return nil, fmt.Errorf("%w: too many search patterns (max %d)", ErrInvalidScanOptions, MaxSearchPatterns)
+	}
+	patterns := make([]*regexp.Regexp, 0, len(searchPatterns))
+	for _, p := range searchPatterns {
+		if len(p) > MaxSearchPatternLength {
+			return nil, fmt.Errorf("%w: search pattern too long (max %d chars)", ErrInvalidScanOptions, MaxSearchPatternLength)
+		}
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid search pattern %q: %v", ErrInvalidScanOptions, p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// scanLineMatches applies the level filter to the parsed level and the search patterns to
+// the parsed message, error and logger fields, and describes which of them matched,
+// e.g. "level:ERROR,pattern:timeout"
+func scanLineMatches(parsed utils.ParsedLog, logLevels map[string]bool, patterns []*regexp.Regexp) (string, bool) {
+	var rules []string
+	if len(logLevels) > 0 {
+		if !logLevels[parsed.Level] {
+			return "", false
+		}
+		rules = append(rules, "level:"+parsed.Level)
 	}
-	var results []map[string]interface{}
-	for _, logLine := range logs {
-		result := map[string]interface{}{
-			"log": logLine,
+	if len(patterns) > 0 {
+		found := ""
+		for _, re := range patterns {
+			for _, field := range parsed.SearchFields() {
+				if re.MatchString(field) {
+					found = strings.TrimPrefix(re.String(), "(?i)")
+					break
+				}
+			}
+			if found != "" {
+				break
+			}
 		}
-		results = append(results, result)
+		if found == "" {
+			return "", false
+		}
+		rules = append(rules, "pattern:"+found)
+	}
+	if len(rules) == 0 {
+		return "all", true
 	}
-	return results, nil
+	return strings.Join(rules, ","), true
 }
 
 func int64Ptr(i int64) *int64 { return &i }
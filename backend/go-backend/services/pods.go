@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ListPodsRequest selects the pods of one namespace, optionally those matching
+// LabelSelector and owned by Owner: a workload as "Kind/name" (e.g. "Deployment/web")
+// or just its name
+type ListPodsRequest struct {
+	Cluster       string
+	Namespace     string
+	LabelSelector string
+	Owner         string
+}
+
+// ListPods returns the pods of a namespace with their status, containers and owning
+// workload, sorted by name
+func (s *DefaultK8sService) ListPods(req ListPodsRequest) ([]models.PodInfo, error) {
+	if req.Cluster == "" || req.Namespace == "" {
+		return nil, ErrInvalidPodRequest
+	}
+	if err := validateSelectors(req.LabelSelector, ""); err != nil {
+		return nil, err
+	}
+	ownerKind, ownerName, err := parseOwner(req.Owner)
+	if err != nil {
+		return nil, err
+	}
+	_, clientset, err := s.clients().Client(req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clientset.CoreV1().Pods(req.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: req.LabelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	workloads := utils.NewWorkloadResolver(clientset, req.Namespace)
+	now := time.Now()
+	infos := []models.PodInfo{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		owner := workloads.WorkloadFor(pod)
+		if ownerName != "" && !ownerMatches(owner, ownerKind, ownerName) {
+			continue
+		}
+		infos = append(infos, podInfo(pod, owner, now))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// parseOwner splits an owner filter into its kind (empty for any kind) and name
+func parseOwner(owner string) (kind, name string, err error) {
+	if owner == "" {
+		return "", "", nil
+	}
+	kind, name, found := strings.Cut(owner, "/")
+	if !found {
+		return "", owner, nil
+	}
+	if kind == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("%w: owner must be Kind/name or a name, got %q", ErrInvalidSelector, owner)
+	}
+	return kind, name, nil
+}
+
+func ownerMatches(owner, kind, name string) bool {
+	ownerKind, ownerName, _ := strings.Cut(owner, "/")
+	return ownerName == name && (kind == "" || strings.EqualFold(ownerKind, kind))
+}
+
+func podInfo(pod *corev1.Pod, owner string, now time.Time) models.PodInfo {
+	info := models.PodInfo{
+		Name:       pod.Name,
+		Namespace:  pod.Namespace,
+		Phase:      string(pod.Status.Phase),
+		Containers: []models.ContainerInfo{},
+		Labels:     pod.Labels,
+		Owner:      owner,
+		Node:       pod.Spec.NodeName,
+		CreatedAt:  pod.CreationTimestamp.Time,
+	}
+	if !info.CreatedAt.IsZero() {
+		info.AgeSeconds = int64(now.Sub(info.CreatedAt).Seconds())
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			info.Ready = cond.Status == corev1.ConditionTrue
+		}
+	}
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	for _, c := range pod.Spec.Containers {
+		container := models.ContainerInfo{Name: c.Name, Image: c.Image, State: "unknown"}
+		if cs, ok := statuses[c.Name]; ok {
+			container.Ready = cs.Ready
+			container.RestartCount = cs.RestartCount
+			switch {
+			case cs.State.Running != nil:
+				container.State = "running"
+			case cs.State.Waiting != nil:
+				container.State, container.Reason = "waiting", cs.State.Waiting.Reason
+			case cs.State.Terminated != nil:
+				container.State, container.Reason = "terminated", cs.State.Terminated.Reason
+			}
+		}
+		if container.Ready {
+			info.ReadyContainers++
+		}
+		info.Restarts += container.RestartCount
+		info.Containers = append(info.Containers, container)
+	}
+	return info
+}
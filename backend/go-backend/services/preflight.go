@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrJobPreflight is returned when a job cannot run against its cluster: the cluster is
+// unknown or unreachable, the namespace does not exist, or the backend lacks a
+// permission the job's sources need there
+var ErrJobPreflight = errors.New("job cannot run in its cluster")
+
+// preflightTimeout bounds the cluster calls of one preflight
+const preflightTimeout = 10 * time.Second
+
+// jobPermission is an access a job needs in the job's namespace
+type jobPermission struct {
+	verb        string
+	group       string
+	resource    string
+	subresource string
+}
+
+func (p jobPermission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	if p.subresource != "" {
+		return p.verb + " " + resource + "/" + p.subresource
+	}
+	return p.verb + " " + resource
+}
+
+// jobPermissions returns the accesses a job needs: listing pods for the logs and health
+// sources, reading their logs for the logs source, watching pods for a watch-mode job,
+// listing and watching events for the events source and listing Deployments for the
+// health source. Resolving a Deployment or a
+// CronJob target lists the ReplicaSets or Jobs between it and its pods.
+func jobPermissions(job models.Job) []jobPermission {
+	var perms []jobPermission
+	logs := utils.JobHasSource(job, models.JobSourceLogs)
+	if logs || utils.JobHasSource(job, models.JobSourceHealth) {
+		perms = append(perms, jobPermission{verb: "list", resource: "pods"})
+	}
+	if logs {
+		perms = append(perms, jobPermission{verb: "get", resource: "pods", subresource: "log"})
+		if job.Mode == models.JobModeWatch {
+			perms = append(perms, jobPermission{verb: "watch", resource: "pods"})
+		}
+	}
+	if utils.JobHasSource(job, models.JobSourceEvents) {
+		perms = append(perms,
+			jobPermission{verb: "list", resource: "events"},
+			jobPermission{verb: "watch", resource: "events"})
+	}
+	if utils.JobHasSource(job, models.JobSourceHealth) {
+		perms = append(perms, jobPermission{verb: "list", group: "apps", resource: "deployments"})
+	}
+	var replicaSets, jobs bool
+	for _, w := range job.Workloads {
+		replicaSets = replicaSets || w.Kind == models.WorkloadDeployment
+		jobs = jobs || w.Kind == models.WorkloadCronJob
+	}
+	if replicaSets {
+		perms = append(perms, jobPermission{verb: "list", group: "apps", resource: "replicasets"})
+	}
+	if jobs {
+		perms = append(perms, jobPermission{verb: "list", group: "batch", resource: "jobs"})
+	}
+	return perms
+}
+
+// preflight checks a job against its cluster before it is saved, so a job that could
+// never run is rejected with what to fix instead of failing on every run. It is
+// skipped when clients is nil.
+func preflight(clients utils.ClientFactory, job models.Job) error {
+	if clients == nil {
+		return nil
+	}
+	cluster, clientset, err := clients.Client(job.Cluster)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJobPreflight, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+
+	// Without permission to get namespaces the access reviews below still tell whether
+	// the job can run
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, job.Namespace, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return fmt.Errorf("%w: namespace %q does not exist in cluster %q", ErrJobPreflight, job.Namespace, cluster)
+	case err != nil && !apierrors.IsForbidden(err):
+		return fmt.Errorf("%w: cannot reach cluster %q: %v", ErrJobPreflight, cluster, err)
+	}
+
+	var denied []string
+	for _, perm := range jobPermissions(job) {
+		review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace:   job.Namespace,
+					Verb:        perm.verb,
+					Group:       perm.group,
+					Resource:    perm.resource,
+					Subresource: perm.subresource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("%w: cannot check access in cluster %q: %v", ErrJobPreflight, cluster, err)
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, perm.String())
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("%w: the backend is not allowed to %s in namespace %q of cluster %q; grant it a Role with these permissions",
+			ErrJobPreflight, strings.Join(denied, ", "), job.Namespace, cluster)
+	}
+	return nil
+}
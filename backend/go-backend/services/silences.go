@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+// SilenceService abstracts silence and maintenance window management for handlers
+type SilenceService interface {
+	CreateSilence(userID string, req SilenceRequest) (models.Silence, error)
+	ListSilences(userID string) ([]models.Silence, error)
+	UpdateSilence(userID, silenceID string, req SilenceRequest) (models.Silence, error)
+	DeleteSilence(userID, silenceID string) error
+}
+
+// DefaultSilenceService implements SilenceService on top of the utils silence store
+type DefaultSilenceService struct{}
+
+type SilenceRequest struct {
+	Namespace   string             `json:"namespace"`
+	PodPattern  string             `json:"pod_pattern"`
+	JobID       string             `json:"job_id"`
+	Severity    string             `json:"severity"`
+	LinePattern string             `json:"line_pattern"`
+	StartsAt    time.Time          `json:"starts_at"`
+	EndsAt      time.Time          `json:"ends_at"`
+	Recurrence  *models.Recurrence `json:"recurrence"`
+	Comment     string             `json:"comment"`
+}
+
+var ErrInvalidSilenceRequest = errors.New("invalid silence request")
+var ErrSilenceNotFound = errors.New("silence not found")
+
+func (s *DefaultSilenceService) CreateSilence(userID string, req SilenceRequest) (models.Silence, error) {
+	now := time.Now()
+	silence := models.Silence{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		CreatedBy: userID,
+		CreatedAt: now,
+	}
+	applySilenceRequest(&silence, req, now)
+	if err := utils.ValidateSilence(silence); err != nil {
+		return models.Silence{}, fmt.Errorf("%w: %v", ErrInvalidSilenceRequest, err)
+	}
+	err := utils.UpdateSilences(userID, func(userSilences []models.Silence) ([]models.Silence, error) {
+		return append(userSilences, silence), nil
+	})
+	if err != nil {
+		return models.Silence{}, err
+	}
+	return silence, nil
+}
+
+func (s *DefaultSilenceService) ListSilences(userID string) ([]models.Silence, error) {
+	userSilences := utils.GetSilences(userID)
+	if userSilences == nil {
+		userSilences = []models.Silence{}
+	}
+	return userSilences, nil
+}
+
+func (s *DefaultSilenceService) UpdateSilence(userID, silenceID string, req SilenceRequest) (models.Silence, error) {
+	var updated models.Silence
+	err := utils.UpdateSilences(userID, func(userSilences []models.Silence) ([]models.Silence, error) {
+		for i, silence := range userSilences {
+			if silence.ID != silenceID {
+				continue
+			}
+			applySilenceRequest(&silence, req, silence.CreatedAt)
+			if err := utils.ValidateSilence(silence); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidSilenceRequest, err)
+			}
+			userSilences[i] = silence
+			updated = silence
+			return userSilences, nil
+		}
+		return nil, ErrSilenceNotFound
+	})
+	if err != nil {
+		return models.Silence{}, err
+	}
+	return updated, nil
+}
+
+func (s *DefaultSilenceService) DeleteSilence(userID, silenceID string) error {
+	return utils.UpdateSilences(userID, func(userSilences []models.Silence) ([]models.Silence, error) {
+		for i, silence := range userSilences {
+			if silence.ID == silenceID {
+				return append(userSilences[:i], userSilences[i+1:]...), nil
+			}
+		}
+		return nil, ErrSilenceNotFound
+	})
+}
+
+// applySilenceRequest copies the editable fields of a request onto a silence;
+// a missing start time defaults to defaultStart
+func applySilenceRequest(silence *models.Silence, req SilenceRequest, defaultStart time.Time) {
+	silence.Namespace = req.Namespace
+	silence.PodPattern = req.PodPattern
+	silence.JobID = req.JobID
+	silence.Severity = req.Severity
+	silence.LinePattern = req.LinePattern
+	silence.StartsAt = req.StartsAt
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = defaultStart
+	}
+	silence.EndsAt = req.EndsAt
+	silence.Recurrence = req.Recurrence
+	silence.Comment = req.Comment
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Limits on live tails
+const (
+	DefaultTailLines = 10
+	MaxTailLines     = 1000
+	MaxTailStreams   = 50 // containers followed by one tail
+	// TailBufferSize is how many lines may wait for a slow client before the
+	// followers stop reading their streams
+	TailBufferSize = 256
+	// maxTailLineBytes bounds a single log line
+	maxTailLineBytes = 1024 * 1024
+)
+
+// Kinds of TailEvent
+const (
+	TailEventLine        = "line"
+	TailEventStreamEnd   = "stream_end"   // a container's log ended, e.g. the container exited
+	TailEventStreamError = "stream_error" // a container's log could not be followed
+)
+
+// TailLogsRequest selects the containers to follow: the pods named in Pods and/or
+// matching LabelSelector in one namespace, optionally narrowed to one container
+type TailLogsRequest struct {
+	Cluster        string
+	Namespace      string
+	Pods           []string
+	LabelSelector  string
+	Container      string
+	LogLevels      []string
+	SearchPatterns []string
+	TailLines      int // lines of history sent before following
+}
+
+// TailEvent is one message of a live tail: a matched line or the end of a container's stream
+type TailEvent struct {
+	Kind      string           `json:"-"`
+	Match     *models.LogMatch `json:"match,omitempty"`
+	Pod       string           `json:"pod,omitempty"`
+	Container string           `json:"container,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+type tailTarget struct {
+	pod       *corev1.Pod
+	container string
+}
+
+// LogTail is a validated live tail whose containers have been resolved; Run follows them
+type LogTail struct {
+	clientset kubernetes.Interface
+	cluster   string
+	namespace string
+	targets   []tailTarget
+	tailLines int64
+	logLevels map[string]bool
+	patterns  []*regexp.Regexp
+}
+
+// TailLogs validates a tail request and resolves the containers it follows, so request
+// errors are reported before any streaming starts
+func (s *DefaultK8sService) TailLogs(req TailLogsRequest) (*LogTail, error) {
+	if req.Namespace == "" {
+		return nil, ErrInvalidScanRequest
+	}
+	if req.TailLines < 0 || req.TailLines > MaxTailLines {
+		return nil, fmt.Errorf("%w: tail_lines must be between 0 and %d", ErrInvalidScanOptions, MaxTailLines)
+	}
+	if len(req.Pods) == 0 && req.LabelSelector == "" {
+		return nil, fmt.Errorf("%w: a pod or a label selector is required", ErrInvalidScanOptions)
+	}
+	patterns, err := compileSearchPatterns(req.SearchPatterns)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSelectors(req.LabelSelector, ""); err != nil {
+		return nil, err
+	}
+	cluster, clientset, err := s.clients().Client(req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clientset.CoreV1().Pods(req.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: req.LabelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var targets []tailTarget
+	matched := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if len(req.Pods) > 0 && !containsName(req.Pods, pod.Name) {
+			continue
+		}
+		matched++
+		for _, c := range pod.Spec.Containers {
+			if req.Container == "" || req.Container == c.Name {
+				targets = append(targets, tailTarget{pod: pod, container: c.Name})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		if matched == 0 {
+			return nil, fmt.Errorf("%w: no pods match the request", ErrInvalidScanOptions)
+		}
+		return nil, fmt.Errorf("%w: no container named %q in the %d matching pod(s)", ErrInvalidScanOptions, req.Container, matched)
+	}
+	if len(targets) > MaxTailStreams {
+		return nil, fmt.Errorf("%w: request matches %d containers (max %d)", ErrInvalidScanOptions, len(targets), MaxTailStreams)
+	}
+	tailLines := req.TailLines
+	if tailLines == 0 {
+		tailLines = DefaultTailLines
+	}
+	return &LogTail{
+		clientset: clientset,
+		cluster:   cluster,
+		namespace: req.Namespace,
+		targets:   targets,
+		tailLines: int64(tailLines),
+		logLevels: utils.LogLevelSet(req.LogLevels),
+		patterns:  patterns,
+	}, nil
+}
+
+// Run follows every container of the tail and passes matched lines to send, tagged with
+// their pod and container, until ctx is done, send fails or every stream has ended.
+// Lines are buffered up to TailBufferSize; beyond that the followers wait for send, so a
+// slow client slows down reading instead of growing memory. Run returns only after all
+// followers have stopped.
+func (t *LogTail) Run(ctx context.Context, send func(TailEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan TailEvent, TailBufferSize)
+	var wg sync.WaitGroup
+	for _, target := range t.targets {
+		wg.Add(1)
+		go func(target tailTarget) {
+			defer wg.Done()
+			t.follow(ctx, target, events)
+		}(target)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	var sendErr error
+	for ev := range events {
+		if sendErr != nil {
+			continue // draining until the cancelled followers stop
+		}
+		if err := send(ev); err != nil {
+			sendErr = err
+			cancel()
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+	return ctx.Err()
+}
+
+// follow streams one container's log into events, ending with a stream_end or
+// stream_error event unless ctx was cancelled
+func (t *LogTail) follow(ctx context.Context, target tailTarget, events chan<- TailEvent) {
+	emit := func(ev TailEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	pod := target.pod
+	opts := &corev1.PodLogOptions{Container: target.container, Follow: true, Timestamps: true, TailLines: &t.tailLines}
+	stream, err := t.clientset.CoreV1().Pods(t.namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			emit(TailEvent{Kind: TailEventStreamError, Pod: pod.Name, Container: target.container, Error: err.Error()})
+		}
+		return
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.Logger.Warn("[K8s] Error closing log tail stream of ", pod.Name, "/", target.container, ": ", err)
+		}
+	}()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTailLineBytes)
+	for scanner.Scan() {
+		ts, line, ok := utils.SplitLogTimestamp(scanner.Text())
+		if !ok {
+			continue
+		}
+		parsed := utils.ParseLogLine(line)
+		rule, matched := scanLineMatches(parsed, t.logLevels, t.patterns)
+		if !matched {
+			continue
+		}
+		match := &models.LogMatch{
+			Cluster:   t.cluster,
+			Namespace: t.namespace,
+			Pod:       pod.Name,
+			Container: target.container,
+			Node:      pod.Spec.NodeName,
+			Timestamp: ts,
+			Level:     parsed.Level,
+			Rule:      rule,
+			Line:      line,
+			Format:    parsed.Format,
+			Message:   parsed.Message,
+			Logger:    parsed.Logger,
+			Error:     parsed.Error,
+		}
+		if !emit(TailEvent{Kind: TailEventLine, Match: match}) {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	end := TailEvent{Kind: TailEventStreamEnd, Pod: pod.Name, Container: target.container}
+	if err := scanner.Err(); err != nil {
+		end = TailEvent{Kind: TailEventStreamError, Pod: pod.Name, Container: target.container, Error: err.Error()}
+	}
+	emit(end)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
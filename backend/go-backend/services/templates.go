@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	"github.com/google/uuid"
+)
+
+// TemplateService abstracts job template management for handlers
+type TemplateService interface {
+	CreateTemplate(userID string, req TemplateRequest) (models.JobTemplate, error)
+	ListTemplates(userID string) ([]models.JobTemplate, error)
+	UpdateTemplate(userID, templateID string, req TemplateRequest) (models.JobTemplate, error)
+	DeleteTemplate(userID, templateID string) error
+	InstantiateTemplate(userID, templateID string, req InstantiateTemplateRequest) ([]models.Job, error)
+	PreviewTemplateApply(userID, templateID string) ([]TemplateJobChange, error)
+	ApplyTemplate(userID, templateID string) ([]TemplateJobChange, error)
+}
+
+// DefaultTemplateService implements TemplateService on top of the utils template and job
+// stores. Jobs it creates or re-renders are checked like any other job, against their
+// cluster too when Clients is set.
+type DefaultTemplateService struct {
+	Clients utils.ClientFactory
+}
+
+type TemplateRequest struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	JobName       string   `json:"job_name"`
+	Team          string   `json:"team"`
+	Cluster       string   `json:"cluster"`
+	Namespace     string   `json:"namespace"`
+	LogLevels     []string `json:"log_levels"`
+	Interval      int      `json:"interval"`
+	Pods          []string `json:"pods"`
+	Microservices []string `json:"microservices"`
+	Mode          string   `json:"mode"`
+	LabelSelector string   `json:"label_selector"`
+	FieldSelector string   `json:"field_selector"`
+	Sources       []string `json:"sources"`
+
+	Workloads  []models.WorkloadRef     `json:"workloads"`
+	Containers *models.ContainerFilter  `json:"containers"`
+	Health     *models.HealthThresholds `json:"health"`
+
+	Multiline []models.MultilineRule `json:"multiline"`
+	Triggers  []models.JobTrigger    `json:"triggers"`
+}
+
+// InstantiateTemplateRequest creates one job per parameter set
+type InstantiateTemplateRequest struct {
+	Params []map[string]string `json:"params"`
+}
+
+// TemplateJobChange describes how applying a template changes one derived job;
+// Changed lists the JSON names of the fields that differ
+type TemplateJobChange struct {
+	JobID   string     `json:"job_id"`
+	Before  models.Job `json:"before"`
+	After   models.Job `json:"after"`
+	Changed []string   `json:"changed"`
+	Error   string     `json:"error,omitempty"`
+}
+
+var ErrInvalidTemplateRequest = errors.New("invalid template request")
+var ErrTemplateNotFound = errors.New("template not found")
+var errJobChangedDuringApply = errors.New("job was changed while the template was applied, apply it again")
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+func (s *DefaultTemplateService) CreateTemplate(userID string, req TemplateRequest) (models.JobTemplate, error) {
+	if err := validateTemplateRequest(req); err != nil {
+		return models.JobTemplate{}, err
+	}
+	now := time.Now()
+	tmpl := models.JobTemplate{ID: uuid.New().String(), UserID: userID, CreatedAt: now}
+	applyTemplateRequest(&tmpl, req, now)
+	err := utils.UpdateTemplates(userID, func(userTemplates []models.JobTemplate) ([]models.JobTemplate, error) {
+		return append(userTemplates, tmpl), nil
+	})
+	if err != nil {
+		return models.JobTemplate{}, err
+	}
+	return tmpl, nil
+}
+
+func (s *DefaultTemplateService) ListTemplates(userID string) ([]models.JobTemplate, error) {
+	userTemplates := utils.GetTemplates(userID)
+	if userTemplates == nil {
+		userTemplates = []models.JobTemplate{}
+	}
+	return userTemplates, nil
+}
+
+// UpdateTemplate changes a template; derived jobs are only changed by ApplyTemplate
+func (s *DefaultTemplateService) UpdateTemplate(userID, templateID string, req TemplateRequest) (models.JobTemplate, error) {
+	if err := validateTemplateRequest(req); err != nil {
+		return models.JobTemplate{}, err
+	}
+	var updated models.JobTemplate
+	err := utils.UpdateTemplates(userID, func(userTemplates []models.JobTemplate) ([]models.JobTemplate, error) {
+		for i, tmpl := range userTemplates {
+			if tmpl.ID != templateID {
+				continue
+			}
+			applyTemplateRequest(&tmpl, req, time.Now())
+			userTemplates[i] = tmpl
+			updated = tmpl
+			return userTemplates, nil
+		}
+		return nil, ErrTemplateNotFound
+	})
+	if err != nil {
+		return models.JobTemplate{}, err
+	}
+	return updated, nil
+}
+
+func (s *DefaultTemplateService) DeleteTemplate(userID, templateID string) error {
+	return utils.UpdateTemplates(userID, func(userTemplates []models.JobTemplate) ([]models.JobTemplate, error) {
+		for i, tmpl := range userTemplates {
+			if tmpl.ID == templateID {
+				return append(userTemplates[:i], userTemplates[i+1:]...), nil
+			}
+		}
+		return nil, ErrTemplateNotFound
+	})
+}
+
+// InstantiateTemplate renders the template once per parameter set and stores the resulting jobs.
+// All parameter sets are rendered and checked first and the jobs are then stored together,
+// so an invalid one creates no jobs at all.
+func (s *DefaultTemplateService) InstantiateTemplate(userID, templateID string, req InstantiateTemplateRequest) ([]models.Job, error) {
+	tmpl, err := findTemplate(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Params) == 0 {
+		return nil, fmt.Errorf("%w: at least one parameter set is required", ErrInvalidTemplateRequest)
+	}
+	var created []models.Job
+	for _, params := range req.Params {
+		jobReq, err := renderTemplate(tmpl, params)
+		if err != nil {
+			return nil, err
+		}
+		job := jobFromRequest(userID, jobReq)
+		job.TemplateID = tmpl.ID
+		job.TemplateParams = params
+		if err := checkJob(s.Clients, userID, "", job); err != nil {
+			return nil, err
+		}
+		created = append(created, job)
+	}
+	err = utils.UpdateJobs(userID, func(jobs []models.Job) ([]models.Job, error) {
+		return append(jobs, created...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// PreviewTemplateApply shows how ApplyTemplate would change every job derived from the template
+func (s *DefaultTemplateService) PreviewTemplateApply(userID, templateID string) ([]TemplateJobChange, error) {
+	tmpl, err := findTemplate(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	changes := []TemplateJobChange{}
+	for _, job := range utils.GetJobs(userID) {
+		if job.TemplateID == tmpl.ID {
+			changes = append(changes, templateChangeFor(tmpl, job))
+		}
+	}
+	return changes, nil
+}
+
+// ApplyTemplate re-renders every derived job from the current template, keeping each
+// job's identity, schedule state and parameters. Jobs that fail to render or whose
+// rendering fails the job checks are left unchanged, as are jobs edited while the
+// checks ran; jobs created meanwhile are kept.
+func (s *DefaultTemplateService) ApplyTemplate(userID, templateID string) ([]TemplateJobChange, error) {
+	tmpl, err := findTemplate(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	changes := []TemplateJobChange{}
+	for _, job := range utils.GetJobs(userID) {
+		if job.TemplateID != tmpl.ID {
+			continue
+		}
+		change := templateChangeFor(tmpl, job)
+		if change.Error == "" {
+			if err := checkJob(s.Clients, userID, job.ID, change.After); err != nil {
+				change.Error = err.Error()
+			}
+		}
+		changes = append(changes, change)
+	}
+	// The checks may call the cluster, so they run unlocked and each job is re-rendered
+	// from its current version under the lock; a job whose rendering now differs from the
+	// checked one, other than in its last run, was edited meanwhile and is skipped.
+	err = utils.UpdateJobs(userID, func(jobs []models.Job) ([]models.Job, error) {
+		for i := range changes {
+			change := &changes[i]
+			if change.Error != "" {
+				continue
+			}
+			idx := findJobIndex(jobs, change.JobID)
+			if idx < 0 {
+				change.Error = ErrJobNotFound.Error()
+				continue
+			}
+			current := templateChangeFor(tmpl, jobs[idx])
+			checked := change.After
+			checked.LastRun = current.After.LastRun
+			if current.Error != "" || !reflect.DeepEqual(checked, current.After) {
+				change.Error = errJobChangedDuringApply.Error()
+				continue
+			}
+			jobs[idx] = current.After
+		}
+		return jobs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func findJobIndex(jobs []models.Job, jobID string) int {
+	for i, job := range jobs {
+		if job.ID == jobID {
+			return i
+		}
+	}
+	return -1
+}
+
+func findTemplate(userID, templateID string) (models.JobTemplate, error) {
+	for _, tmpl := range utils.GetTemplates(userID) {
+		if tmpl.ID == templateID {
+			return tmpl, nil
+		}
+	}
+	return models.JobTemplate{}, ErrTemplateNotFound
+}
+
+// templateChangeFor renders a template with a job's stored parameters and diffs the result
+// against the job. Settings the template leaves unset beyond its original fields (mode,
+// selectors, sources, workloads, containers, health, multi-line rules and triggers) keep
+// the job's own value, so templates saved before they existed do not clear them.
+func templateChangeFor(tmpl models.JobTemplate, job models.Job) TemplateJobChange {
+	change := TemplateJobChange{JobID: job.ID, Before: job, After: job, Changed: []string{}}
+	jobReq, err := renderTemplate(tmpl, job.TemplateParams)
+	if err != nil {
+		change.Error = err.Error()
+		return change
+	}
+	rendered := jobFromRequest(job.UserID, jobReq)
+	after := job
+	after.Name = rendered.Name
+	after.Team = rendered.Team
+	after.Cluster = rendered.Cluster
+	after.Namespace = rendered.Namespace
+	after.LogLevels = rendered.LogLevels
+	after.Interval = rendered.Interval
+	after.Pods = rendered.Pods
+	after.Microservices = rendered.Microservices
+	if rendered.Mode != "" {
+		after.Mode = rendered.Mode
+	}
+	if rendered.LabelSelector != "" {
+		after.LabelSelector = rendered.LabelSelector
+	}
+	if rendered.FieldSelector != "" {
+		after.FieldSelector = rendered.FieldSelector
+	}
+	if len(rendered.Sources) > 0 {
+		after.Sources = rendered.Sources
+	}
+	if len(rendered.Workloads) > 0 {
+		after.Workloads = rendered.Workloads
+	}
+	if rendered.Containers != nil {
+		after.Containers = rendered.Containers
+	}
+	if rendered.Health != nil {
+		after.Health = rendered.Health
+	}
+	if len(rendered.Multiline) > 0 {
+		after.Multiline = rendered.Multiline
+	}
+	if len(rendered.Triggers) > 0 {
+		after.Triggers = rendered.Triggers
+	}
+	change.After = after
+
+	before := reflect.ValueOf(job)
+	afterVal := reflect.ValueOf(after)
+	for i := 0; i < before.NumField(); i++ {
+		name := jsonFieldName(before.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(before.Field(i).Interface(), afterVal.Field(i).Interface()) {
+			change.Changed = append(change.Changed, name)
+		}
+	}
+	return change
+}
+
+// jsonFieldName returns the name a struct field has in the API, or "" for fields that are not serialized
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// renderTemplate substitutes {{placeholders}} in every string field of a template
+func renderTemplate(tmpl models.JobTemplate, params map[string]string) (CreateJobRequest, error) {
+	var missing []string
+	render := func(value string) string {
+		return placeholderPattern.ReplaceAllStringFunc(value, func(m string) string {
+			key := placeholderPattern.FindStringSubmatch(m)[1]
+			v, ok := params[key]
+			if !ok {
+				missing = append(missing, key)
+			}
+			return v
+		})
+	}
+	renderAll := func(values []string) []string {
+		if values == nil {
+			return nil
+		}
+		out := make([]string, len(values))
+		for i, v := range values {
+			out[i] = render(v)
+		}
+		return out
+	}
+	req := CreateJobRequest{
+		Name:          render(tmpl.JobName),
+		Team:          render(tmpl.Team),
+		Cluster:       render(tmpl.Cluster),
+		Namespace:     render(tmpl.Namespace),
+		LogLevels:     renderAll(tmpl.LogLevels),
+		Interval:      tmpl.Interval,
+		Pods:          renderAll(tmpl.Pods),
+		Microservices: append([]string(nil), tmpl.Microservices...),
+		Mode:          tmpl.Mode,
+		LabelSelector: render(tmpl.LabelSelector),
+		FieldSelector: render(tmpl.FieldSelector),
+		Sources:       append([]string(nil), tmpl.Sources...),
+		Containers:    tmpl.Containers,
+		Health:        tmpl.Health,
+		Multiline:     append([]models.MultilineRule(nil), tmpl.Multiline...),
+		Triggers:      append([]models.JobTrigger(nil), tmpl.Triggers...),
+	}
+	for _, w := range tmpl.Workloads {
+		req.Workloads = append(req.Workloads, models.WorkloadRef{Kind: w.Kind, Name: render(w.Name)})
+	}
+	if req.Name == "" {
+		req.Name = tmpl.Name
+	}
+	if len(missing) > 0 {
+		return CreateJobRequest{}, fmt.Errorf("%w: missing value for placeholder(s) %v", ErrInvalidTemplateRequest, missing)
+	}
+	if req.Namespace == "" || req.Interval <= 0 {
+		return CreateJobRequest{}, fmt.Errorf("%w: rendered job needs a namespace and a positive interval", ErrInvalidTemplateRequest)
+	}
+	return req, nil
+}
+
+func validateTemplateRequest(req TemplateRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTemplateRequest)
+	}
+	if req.Namespace == "" || req.Interval <= 0 {
+		return fmt.Errorf("%w: namespace and a positive interval are required", ErrInvalidTemplateRequest)
+	}
+	return nil
+}
+
+func applyTemplateRequest(tmpl *models.JobTemplate, req TemplateRequest, now time.Time) {
+	tmpl.Name = req.Name
+	tmpl.Description = req.Description
+	tmpl.JobName = req.JobName
+	tmpl.Team = req.Team
+	tmpl.Cluster = req.Cluster
+	tmpl.Namespace = req.Namespace
+	tmpl.LogLevels = req.LogLevels
+	tmpl.Interval = req.Interval
+	tmpl.Pods = req.Pods
+	tmpl.Microservices = req.Microservices
+	tmpl.Mode = req.Mode
+	tmpl.LabelSelector = req.LabelSelector
+	tmpl.FieldSelector = req.FieldSelector
+	tmpl.Sources = req.Sources
+	tmpl.Workloads = req.Workloads
+	tmpl.Containers = req.Containers
+	tmpl.Health = req.Health
+	tmpl.Multiline = req.Multiline
+	tmpl.Triggers = req.Triggers
+	tmpl.UpdatedAt = now
+}
@@ -6,12 +6,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"backend/go-backend/handlers"
 	"backend/go-backend/services"
 	testhelpers "backend/go-backend/testhelpers"
 	"backend/go-backend/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestJobAPIHandlers(t *testing.T) {
@@ -96,3 +101,119 @@ func TestJobAPIHandlers(t *testing.T) {
 		t.Fatalf("Expected no incidents, got %+v", incidents)
 	}
 }
+
+func TestPreviewLogScanJobRequiresNamespace(t *testing.T) {
+	userID := "previewuser"
+	utils.ClearJobs()
+	jobService := &services.DefaultJobService{}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":       "Preview Job",
+		"log_levels": []string{"ERROR"},
+	})
+	r := httptest.NewRequest("POST", "/api/log-scan-jobs/preview", bytes.NewReader(body))
+	r = testhelpers.WithUser(r, userID)
+	w := httptest.NewRecorder()
+	handlers.HandlePreviewLogScanJob(jobService)(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for preview without namespace, got %d %s", w.Code, w.Body.String())
+	}
+	if jobs := utils.GetJobs(userID); len(jobs) != 0 {
+		t.Fatalf("Preview must not create jobs, got %+v", jobs)
+	}
+}
+
+func TestPreviewLogScanJobUsesInjectedClientsAndPersistsNothing(t *testing.T) {
+	userID := "fakepreviewuser"
+	utils.ClearJobs()
+	utils.ClearIncidents()
+	cursorsFile := utils.CursorsFile
+	utils.CursorsFile = filepath.Join(t.TempDir(), "cursors.json")
+	defer func() { utils.CursorsFile = cursorsFile }()
+	client := fakeLogsClientset{
+		Clientset: fake.NewSimpleClientset(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "app", ContainerID: "containerd://app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}}},
+		}),
+		logs: map[string][]string{"web-1": {"INFO request served", "ERROR upstream timeout"}},
+	}
+	jobService := &services.DefaultJobService{Clients: fakeClients{name: "fake", client: client}}
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Preview Job", "namespace": "shop", "log_levels": []string{"ERROR"}})
+	r := httptest.NewRequest("POST", "/api/log-scan-jobs/preview", bytes.NewReader(body))
+	r = testhelpers.WithUser(r, userID)
+	w := httptest.NewRecorder()
+	handlers.HandlePreviewLogScanJob(jobService)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Preview failed: %d %s", w.Code, w.Body.String())
+	}
+	var preview utils.JobPreview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("failed to unmarshal preview: %v", err)
+	}
+	if len(preview.Pods) != 1 || len(preview.Matches) != 1 {
+		t.Fatalf("Expected one pod and one match, got %+v", preview)
+	}
+	if m := preview.Matches[0]; m.Line != "ERROR upstream timeout" || m.Cluster != "fake" || m.Pod != "web-1" || m.Severity != "High" {
+		t.Errorf("Unexpected match %+v", m)
+	}
+	if jobs := utils.GetJobs(userID); len(jobs) != 0 {
+		t.Errorf("Preview must not create jobs, got %+v", jobs)
+	}
+	if incidents := utils.GetRecentIncidents(userID); len(incidents) != 0 {
+		t.Errorf("Preview must not store incidents, got %+v", incidents)
+	}
+	if _, err := os.Stat(utils.CursorsFile); !os.IsNotExist(err) {
+		t.Errorf("Preview must not save cursors, got %v", err)
+	}
+}
+
+func TestPodSelectorsAreValidatedOnSave(t *testing.T) {
+	userID := "selectoruser"
+	utils.ClearJobs()
+	utils.JobsFile = "test_jobs_selectors.json"
+	defer func() {
+		if err := os.Remove(utils.JobsFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove jobs file: %v", err)
+		}
+	}()
+	jobService := &services.DefaultJobService{}
+
+	create := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		r := httptest.NewRequest("POST", "/api/log-scan-jobs", bytes.NewReader(body))
+		r = testhelpers.WithUser(r, userID)
+		w := httptest.NewRecorder()
+		handlers.HandleCreateLogScanJob(jobService)(w, r)
+		return w
+	}
+	if w := create(map[string]interface{}{"namespace": "default", "interval": 60, "label_selector": "app in (web"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid label selector, got %d %s", w.Code, w.Body.String())
+	}
+	if w := create(map[string]interface{}{"namespace": "default", "interval": 60, "field_selector": "status.phase~Running"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid field selector, got %d %s", w.Code, w.Body.String())
+	}
+	w := create(map[string]interface{}{
+		"namespace":      "default",
+		"interval":       60,
+		"label_selector": "app=web,tier in (frontend,edge)",
+		"field_selector": "status.phase=Running",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create job with selectors failed: %d %s", w.Code, w.Body.String())
+	}
+	if jobs := utils.GetJobs(userID); len(jobs) != 1 || jobs[0].LabelSelector != "app=web,tier in (frontend,edge)" || jobs[0].FieldSelector != "status.phase=Running" {
+		t.Fatalf("Expected selectors to be stored, got %+v", jobs)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"namespaces": []string{"default"}, "pod_labels": map[string]string{"app": "bad value!"}})
+	r := httptest.NewRequest("POST", "/scan-k8s-logs", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handlers.HandleScanK8sLogs(&services.DefaultK8sService{})(rec, r)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid pod_labels, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	fakerest "k8s.io/client-go/rest/fake"
+)
+
+// fakeClients hands out one client for the cluster it names, which is also the default
+type fakeClients struct {
+	name   string
+	client kubernetes.Interface
+}
+
+func (f fakeClients) Client(cluster string) (string, kubernetes.Interface, error) {
+	if cluster != "" && cluster != f.name {
+		return "", nil, fmt.Errorf("%w: %s", utils.ErrUnknownCluster, cluster)
+	}
+	return f.name, f.client, nil
+}
+
+// fakeLogsClientset is a fake clientset whose pods' logs are the given lines, keyed by
+// pod name; the plain fake clientset returns the same untimestamped line for every pod
+type fakeLogsClientset struct {
+	*fake.Clientset
+	logs map[string][]string
+}
+
+func (c fakeLogsClientset) CoreV1() typedcorev1.CoreV1Interface {
+	return fakeLogsCoreV1{CoreV1Interface: c.Clientset.CoreV1(), logs: c.logs}
+}
+
+type fakeLogsCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	logs map[string][]string
+}
+
+func (c fakeLogsCoreV1) Pods(namespace string) typedcorev1.PodInterface {
+	return fakeLogsPods{PodInterface: c.CoreV1Interface.Pods(namespace), logs: c.logs}
+}
+
+type fakeLogsPods struct {
+	typedcorev1.PodInterface
+	logs map[string][]string
+}
+
+// GetLogs serves the pod's lines stamped with podLogTime
+func (p fakeLogsPods) GetLogs(name string, _ *corev1.PodLogOptions) *rest.Request {
+	var body strings.Builder
+	for _, line := range p.logs[name] {
+		fmt.Fprintf(&body, "%s %s\n", podLogTime.Format(time.RFC3339Nano), line)
+	}
+	client := &fakerest.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		Client: fakerest.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body.String()))}, nil
+		}),
+	}
+	return client.Request()
+}
+
+func TestK8sServiceUsesInjectedClients(t *testing.T) {
+	clients := fakeClients{name: "fake", client: fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "billing"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "invoice-1", Namespace: "billing"}},
+	)}
+	svc := &services.DefaultK8sService{Clients: clients}
+
+	namespaces, err := svc.ListNamespaces("")
+	if err != nil {
+		t.Fatalf("ListNamespaces failed: %v", err)
+	}
+	sort.Strings(namespaces)
+	if fmt.Sprint(namespaces) != "[billing shop]" {
+		t.Fatalf("Expected the fake cluster's namespaces, got %v", namespaces)
+	}
+	pods, err := svc.ListPods(services.ListPodsRequest{Cluster: "fake", Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("ListPods failed: %v", err)
+	}
+	if len(pods) != 2 || pods[0].Name != "web-1" || pods[1].Name != "web-2" {
+		t.Fatalf("Expected the pods of shop, got %+v", pods)
+	}
+	if _, err := svc.ListPods(services.ListPodsRequest{Cluster: "other", Namespace: "shop"}); !errors.Is(err, services.ErrUnknownCluster) {
+		t.Fatalf("Expected ErrUnknownCluster for an unknown cluster, got %v", err)
+	}
+}
+
+func TestJobExecutorUsesInjectedClients(t *testing.T) {
+	clients := fakeClients{name: "fake", client: fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "app", RestartCount: 4,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: "shop", UID: "ev-1"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1", Namespace: "shop"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedMount",
+			Message:        `MountVolume.SetUp failed for volume "config"`,
+			Count:          1,
+		},
+	)}
+	job := models.Job{
+		ID: "fake-clients-job", Namespace: "shop",
+		Sources: []string{models.JobSourceEvents, models.JobSourceHealth},
+	}
+	defer utils.ForgetJobFindings(job.ID)
+
+	incidents, err := utils.DefaultJobExecutor{Clients: clients}.Run("clientsuser", job)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	reasons := make(map[string]models.Incident)
+	for _, inc := range incidents {
+		reasons[inc.Source+" "+inc.Reason] = inc
+		if inc.Cluster != "fake" || inc.Pod != "web-1" {
+			t.Errorf("Expected an incident of web-1 on the fake cluster, got %+v", inc)
+		}
+	}
+	if len(incidents) != 2 {
+		t.Fatalf("Expected an event and a health incident, got %+v", incidents)
+	}
+	if _, ok := reasons[models.JobSourceEvents+" FailedMount"]; !ok {
+		t.Errorf("Expected the FailedMount event, got %+v", incidents)
+	}
+	if _, ok := reasons[models.JobSourceHealth+" "+utils.HealthCrashLoop]; !ok {
+		t.Errorf("Expected the crash loop, got %+v", incidents)
+	}
+}
+
+func TestRegistryClientsAreCachedPerCluster(t *testing.T) {
+	useTestCluster(t, http.NotFoundHandler())
+	name, first, err := utils.RegistryClients{}.Client("")
+	if err != nil || name != "test" {
+		t.Fatalf("Expected the default cluster's client, got %q %v", name, err)
+	}
+	second, err := utils.ClientForCluster("test")
+	if err != nil {
+		t.Fatalf("ClientForCluster failed: %v", err)
+	}
+	if first != second {
+		t.Fatal("Expected one client per cluster to be reused")
+	}
+}
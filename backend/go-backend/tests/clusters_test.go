@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: %s
+- name: prod
+  cluster:
+    server: %s
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: tester
+- name: prod
+  context:
+    cluster: prod
+    user: tester
+users:
+- name: tester
+  user:
+    token: test-token
+`
+
+func TestClusterRegistryFromKubeconfig(t *testing.T) {
+	staging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/version" {
+			fmt.Fprint(w, `{"major":"1","minor":"29","gitVersion":"v1.29.0"}`)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer staging.Close()
+	prod := httptest.NewServer(http.NotFoundHandler())
+	prod.Close() // unreachable
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testKubeconfig, staging.URL, prod.URL)), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	t.Setenv("CLUSTER_CREDENTIALS_FILE", "")
+	defer utils.ResetClusterRegistryForTest()
+	if err := utils.LoadClusterRegistry(); err != nil {
+		t.Fatalf("LoadClusterRegistry failed: %v", err)
+	}
+
+	r := httptest.NewRequest("GET", "/k8s-clusters", nil)
+	w := httptest.NewRecorder()
+	handlers.HandleK8sClusters(&services.DefaultK8sService{})(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Clusters []models.ClusterInfo `json:"clusters"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal clusters: %v", err)
+	}
+	byName := map[string]models.ClusterInfo{}
+	for _, c := range resp.Clusters {
+		byName[c.Cluster] = c
+	}
+	if c := byName["staging"]; !c.Reachable || !c.Default || c.Version != "v1.29.0" || c.Source != utils.ClusterSourceKubeconfig {
+		t.Errorf("Unexpected staging cluster: %+v", c)
+	}
+	if c, ok := byName["prod"]; !ok || c.Reachable || c.Error == "" {
+		t.Errorf("Expected prod to be listed as unreachable, got %+v", c)
+	}
+
+	if _, err := utils.ClientForCluster("missing"); !errors.Is(err, utils.ErrUnknownCluster) {
+		t.Fatalf("Expected ErrUnknownCluster, got %v", err)
+	}
+	r = httptest.NewRequest("GET", "/k8s-namespaces?cluster=missing", nil)
+	w = httptest.NewRecorder()
+	handlers.HandleK8sNamespaces(&services.DefaultK8sService{})(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 for unknown cluster, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// useTestCluster registers an API server stub as the only, default cluster "test"
+func useTestCluster(t *testing.T, handler http.Handler) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	path := filepath.Join(t.TempDir(), "config")
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: tester
+users:
+- name: tester
+  user:
+    token: test-token
+`, server.URL)
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	t.Setenv("CLUSTER_CREDENTIALS_FILE", "")
+	t.Cleanup(utils.ResetClusterRegistryForTest)
+	if err := utils.LoadClusterRegistry(); err != nil {
+		t.Fatalf("LoadClusterRegistry failed: %v", err)
+	}
+}
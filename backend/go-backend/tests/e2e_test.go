@@ -136,7 +136,7 @@ func TestE2E_LogScanJobToIncident(t *testing.T) {
 	}
 
 	// Start scheduler
-	go utils.StartScheduler()
+	go utils.StartScheduler(nil)
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("recovered in defer: %v", r)
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+)
+
+// eventServer serves the given events.k8s.io/v1 and core/v1 event items for every namespace
+func eventServer(v1Items, coreItems []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/apis/events.k8s.io/v1/") && strings.HasSuffix(r.URL.Path, "/events"):
+			fmt.Fprintf(w, `{"kind":"EventList","apiVersion":"events.k8s.io/v1","items":[%s]}`, strings.Join(v1Items, ","))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/") && strings.HasSuffix(r.URL.Path, "/events"):
+			fmt.Fprintf(w, `{"kind":"EventList","apiVersion":"v1","items":[%s]}`, strings.Join(coreItems, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestEventSourceCreatesDeduplicatedIncidents(t *testing.T) {
+	useTestCluster(t, eventServer(
+		[]string{
+			`{"metadata":{"name":"e1","namespace":"shop","uid":"uid-1"},"reason":"FailedScheduling","note":"0/3 nodes are available",` +
+				`"type":"Warning","regarding":{"kind":"Pod","name":"web-1"},"deprecatedLastTimestamp":"2024-06-04T10:30:00Z"}`,
+			`{"metadata":{"name":"e2","namespace":"shop","uid":"uid-2"},"reason":"BackOff","note":"Back-off pulling image \"shop:bad\"",` +
+				`"type":"Warning","regarding":{"kind":"Pod","name":"web-2"},"deprecatedLastTimestamp":"2024-06-04T10:31:00Z"}`,
+		},
+		[]string{
+			// The same stored event as uid-1, seen through the core API
+			`{"metadata":{"name":"e1","namespace":"shop","uid":"uid-1"},"reason":"FailedScheduling","message":"0/3 nodes are available",` +
+				`"type":"Warning","involvedObject":{"kind":"Pod","name":"web-1"},"lastTimestamp":"2024-06-04T10:30:00Z"}`,
+			`{"metadata":{"name":"e3","namespace":"shop","uid":"uid-3"},"reason":"FailedMount","message":"volume not found",` +
+				`"type":"Warning","involvedObject":{"kind":"Pod","name":"db-0"},"source":{"host":"node-2"},"lastTimestamp":"2024-06-04T10:32:00Z"}`,
+		},
+	))
+	job := models.Job{ID: "events-job", Name: "shop events", Namespace: "shop", Sources: []string{models.JobSourceEvents}}
+	defer utils.ForgetJobFindings(job.ID)
+
+	incidents, err := utils.RunLogScanJob("eventuser", job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob failed: %v", err)
+	}
+	if len(incidents) != 3 {
+		t.Fatalf("Expected one incident per distinct event, got %+v", incidents)
+	}
+	want := map[string][2]string{
+		"Pod/web-1": {"High", "Scheduling"},
+		"Pod/web-2": {"High", "Image"},
+		"Pod/db-0":  {"High", "Storage"},
+	}
+	for _, inc := range incidents {
+		w, ok := want[inc.Object]
+		if !ok || inc.Severity != w[0] || inc.Category != w[1] {
+			t.Errorf("Unexpected severity or category for %+v", inc)
+		}
+		if inc.Source != models.JobSourceEvents || inc.Cluster != "test" || inc.Namespace != "shop" || inc.Fingerprint == "" || inc.Pod == "" {
+			t.Errorf("Expected event provenance on %+v", inc)
+		}
+	}
+
+	again, err := utils.RunLogScanJob("eventuser", job)
+	if err != nil {
+		t.Fatalf("second RunLogScanJob failed: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("Expected events already reported to be skipped, got %+v", again)
+	}
+}
+
+func TestFindingsSurviveRestartAndSilencedOnesAreNotAnalysed(t *testing.T) {
+	useTestCluster(t, eventServer(nil, []string{
+		`{"metadata":{"name":"e1","namespace":"shop","uid":"uid-1"},"reason":"FailedMount","message":"volume not found",` +
+			`"type":"Warning","involvedObject":{"kind":"Pod","name":"db-0"},"lastTimestamp":"2024-06-04T10:30:00Z"}`,
+		`{"metadata":{"name":"e2","namespace":"shop","uid":"uid-2"},"reason":"BackOff","message":"Back-off pulling image",` +
+			`"type":"Warning","involvedObject":{"kind":"Pod","name":"web-1"},"lastTimestamp":"2024-06-04T10:31:00Z"}`,
+	}))
+	var mu sync.Mutex
+	var analysed []string
+	analyzer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Logs []string `json:"logs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid analyzer request: %v", err)
+		}
+		mu.Lock()
+		analysed = append(analysed, req.Logs...)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer analyzer.Close()
+	t.Setenv("LOG_ANALYZER_URL", analyzer.URL)
+	utils.ClearIncidents()
+	utils.IncidentsFile = "test_incidents_findings.json"
+	utils.SilencesFile = "test_silences_findings.json"
+	defer func() {
+		utils.ClearIncidents()
+		utils.ClearSilences()
+		for _, f := range []string{utils.IncidentsFile, utils.SilencesFile} {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				t.Errorf("failed to remove %s: %v", f, err)
+			}
+		}
+	}()
+	userID := "findingsuser"
+	if err := utils.SetSilences(userID, []models.Silence{{ID: "sil-web", PodPattern: "web-*", EndsAt: time.Now().Add(time.Hour)}}); err != nil {
+		t.Fatalf("SetSilences failed: %v", err)
+	}
+	job := models.Job{ID: "findings-job", Namespace: "shop", Sources: []string{models.JobSourceEvents}, Microservices: []string{"log_analyzer"}}
+	defer utils.ForgetJobFindings(job.ID)
+
+	incidents, err := utils.RunLogScanJob(userID, job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob failed: %v", err)
+	}
+	if len(incidents) != 2 {
+		t.Fatalf("Expected an incident per event, got %+v", incidents)
+	}
+	for _, inc := range incidents {
+		if silenced := inc.Pod == "web-1"; silenced != (inc.Status == "Suppressed" && inc.SilenceID == "sil-web") {
+			t.Errorf("Expected only web-1's event to be suppressed, got %+v", inc)
+		}
+		if err := utils.AddIncident(userID, inc); err != nil {
+			t.Fatalf("AddIncident failed: %v", err)
+		}
+	}
+	mu.Lock()
+	if len(analysed) != 1 || !strings.Contains(analysed[0], "volume not found") {
+		t.Errorf("Expected only the unsilenced event to be analysed, got %q", analysed)
+	}
+	mu.Unlock()
+
+	// A restart loses the in-memory state; the stored incidents still mark the events as seen
+	utils.ForgetJobFindings(job.ID)
+	utils.SeedFindingsFromIncidents()
+	again, err := utils.RunLogScanJob(userID, job)
+	if err != nil {
+		t.Fatalf("second RunLogScanJob failed: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("Expected events stored before the restart to be skipped, got %+v", again)
+	}
+}
+
+func TestJobSourcesAreValidated(t *testing.T) {
+	jobService := &services.DefaultJobService{}
+	_, err := jobService.CreateLogScanJob("sourceuser", services.CreateJobRequest{
+		Namespace: "default", Interval: 60, Sources: []string{"metrics"},
+	})
+	if !errors.Is(err, services.ErrInvalidJobSource) {
+		t.Fatalf("Expected ErrInvalidJobSource, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+)
+
+func TestFairQueueRoundRobinAcrossUsers(t *testing.T) {
+	q := utils.NewFairQueue(utils.QuotaConfig{MaxConcurrent: 3, PerUser: 2})
+	now := time.Now()
+	// One noisy user with many jobs and a quiet user with a single job
+	for i := 0; i < 10; i++ {
+		q.Enqueue("noisy", models.Job{ID: "noisy-" + strconv.Itoa(i)}, now)
+	}
+	q.Enqueue("quiet", models.Job{ID: "quiet-0"}, now)
+
+	var started []utils.QueuedJob
+	for {
+		item, ok := q.Next(now)
+		if !ok {
+			break
+		}
+		started = append(started, item)
+	}
+	if len(started) != 3 {
+		t.Fatalf("Expected 3 jobs to start, got %d", len(started))
+	}
+	perUser := map[string]int{}
+	for _, item := range started {
+		perUser[item.UserID]++
+	}
+	if perUser["quiet"] != 1 || perUser["noisy"] != 2 {
+		t.Fatalf("Expected quiet user to get a slot and noisy user capped at 2, got %+v", perUser)
+	}
+	if q.Depth() != 8 {
+		t.Fatalf("Expected 8 waiting jobs, got %d", q.Depth())
+	}
+
+	// Releasing a noisy job frees a slot for the next noisy job
+	q.Done(started[0])
+	if _, ok := q.Next(now); !ok {
+		t.Fatalf("Expected a job to start after a slot was released")
+	}
+}
+
+func TestFairQueueTeamLimitAndDedup(t *testing.T) {
+	q := utils.NewFairQueue(utils.QuotaConfig{MaxConcurrent: 10, PerUser: 5, PerTeam: 1})
+	now := time.Now()
+	if !q.Enqueue("alice", models.Job{ID: "a1", Team: "payments"}, now) {
+		t.Fatalf("Expected first enqueue to succeed")
+	}
+	if q.Enqueue("alice", models.Job{ID: "a1", Team: "payments"}, now) {
+		t.Fatalf("Expected duplicate enqueue of a pending job to be rejected")
+	}
+	q.Enqueue("bob", models.Job{ID: "b1", Team: "payments"}, now)
+	q.Enqueue("bob", models.Job{ID: "b2", Team: "search"}, now)
+
+	var started []string
+	for {
+		item, ok := q.Next(now)
+		if !ok {
+			break
+		}
+		started = append(started, item.Job.ID)
+	}
+	if len(started) != 2 {
+		t.Fatalf("Expected one payments job and one search job to start, got %v", started)
+	}
+	if q.Running() != 2 || q.Depth() != 1 {
+		t.Fatalf("Expected 2 running and 1 waiting, got running=%d depth=%d", q.Running(), q.Depth())
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+)
+
+func TestHealthSourceReportsPodAndWorkloadProblems(t *testing.T) {
+	hourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	controller := func(kind, name string) string {
+		return fmt.Sprintf(`"ownerReferences":[{"apiVersion":"v1","kind":%q,"name":%q,"uid":"u","controller":true}]`, kind, name)
+	}
+	responses := map[string]string{
+		"/api/v1/namespaces/shop/pods": `{"kind":"PodList","apiVersion":"v1","items":[` +
+			`{"metadata":{"name":"web-abc-1","namespace":"shop",` + controller("ReplicaSet", "web-abc") + `},"spec":{"containers":[{"name":"app"}]},` +
+			`"status":{"phase":"Running","containerStatuses":[{"name":"app","restartCount":7,"state":{"waiting":{"reason":"CrashLoopBackOff"}},` +
+			`"lastState":{"terminated":{"reason":"Error","exitCode":1}}}]}},` +
+			`{"metadata":{"name":"nightly-123-x","namespace":"shop","creationTimestamp":"` + hourAgo + `",` + controller("Job", "nightly-123") + `},` +
+			`"spec":{"containers":[{"name":"task"}]},"status":{"phase":"Pending","conditions":[{"type":"PodScheduled","status":"False","message":"0/3 nodes are available"}]}},` +
+			`{"metadata":{"name":"api-1","namespace":"shop"},"spec":{"containers":[{"name":"api"}]},` +
+			`"status":{"phase":"Running","containerStatuses":[{"name":"api","restartCount":6,"ready":true,"state":{"running":{}}}]}}]}`,
+		"/apis/apps/v1/namespaces/shop/replicasets": `{"kind":"ReplicaSetList","apiVersion":"apps/v1","items":[` +
+			`{"metadata":{"name":"web-abc","namespace":"shop",` + controller("Deployment", "web") + `},"spec":{"selector":{}}}]}`,
+		"/apis/batch/v1/namespaces/shop/jobs": `{"kind":"JobList","apiVersion":"batch/v1","items":[` +
+			`{"metadata":{"name":"nightly-123","namespace":"shop",` + controller("CronJob", "nightly") + `},"spec":{"template":{"spec":{"containers":[]}}}}]}`,
+		"/apis/apps/v1/namespaces/shop/deployments": `{"kind":"DeploymentList","apiVersion":"apps/v1","items":[` +
+			`{"metadata":{"name":"web","namespace":"shop"},"spec":{"selector":{},"template":{"metadata":{"labels":{"app":"web"}}}},` +
+			`"status":{"replicas":2,"unavailableReplicas":2,"conditions":[{"type":"Available","status":"False","lastTransitionTime":"` + hourAgo + `"}]}},` +
+			`{"metadata":{"name":"healthy","namespace":"shop"},"spec":{"selector":{},"template":{"metadata":{"labels":{"app":"healthy"}}}},` +
+			`"status":{"replicas":2,"availableReplicas":2}}]}`,
+	}
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	job := models.Job{ID: "health-job", Name: "shop health", Namespace: "shop", Sources: []string{models.JobSourceHealth}}
+	defer utils.ForgetJobFindings(job.ID)
+
+	incidents, err := utils.RunLogScanJob("healthuser", job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob failed: %v", err)
+	}
+	got := make(map[string]models.Incident)
+	for _, inc := range incidents {
+		got[inc.Reason+" "+inc.Object] = inc
+	}
+	want := map[string]string{
+		utils.HealthCrashLoop + " Pod/web-abc-1":              "Deployment/web",
+		utils.HealthPodPending + " Pod/nightly-123-x":         "CronJob/nightly",
+		utils.HealthHighRestarts + " Pod/api-1":               "",
+		utils.HealthDeploymentUnavailable + " Deployment/web": "Deployment/web",
+	}
+	if len(incidents) != len(want) {
+		t.Fatalf("Expected %d health incidents, got %+v", len(want), incidents)
+	}
+	for key, workload := range want {
+		inc, ok := got[key]
+		if !ok {
+			t.Errorf("Missing incident %q in %+v", key, incidents)
+			continue
+		}
+		if inc.Workload != workload || inc.Source != models.JobSourceHealth || inc.Cluster != "test" {
+			t.Errorf("Unexpected provenance for %q: %+v", key, inc)
+		}
+	}
+	if crash := got[utils.HealthCrashLoop+" Pod/web-abc-1"]; crash.Severity != "Critical" || crash.Restart == nil || crash.Restart.RestartCount != 7 {
+		t.Errorf("Expected a critical crash loop incident with restart details, got %+v", crash)
+	}
+	if pending := got[utils.HealthPodPending+" Pod/nightly-123-x"]; !strings.Contains(pending.LogLine, "0/3 nodes are available") {
+		t.Errorf("Expected the scheduling message in the pending incident, got %q", pending.LogLine)
+	}
+
+	// Problems that persist are not reported again
+	again, err := utils.RunLogScanJob("healthuser", job)
+	if err != nil {
+		t.Fatalf("second RunLogScanJob failed: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("Expected ongoing problems to be deduplicated, got %+v", again)
+	}
+
+	// A higher threshold silences the restart count check
+	job.ID = "health-job-thresholds"
+	job.Health = &models.HealthThresholds{RestartCount: 10}
+	defer utils.ForgetJobFindings(job.ID)
+	incidents, err = utils.RunLogScanJob("healthuser", job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob with thresholds failed: %v", err)
+	}
+	for _, inc := range incidents {
+		if inc.Reason == utils.HealthHighRestarts {
+			t.Errorf("Expected restart threshold to be honoured, got %+v", inc)
+		}
+	}
+	// A job targeting other workloads does not report the unavailable Deployment
+	job = models.Job{ID: "health-job-cronjob", Namespace: "shop", Sources: []string{models.JobSourceHealth},
+		Workloads: []models.WorkloadRef{{Kind: models.WorkloadCronJob, Name: "nightly"}}}
+	defer utils.ForgetJobFindings(job.ID)
+	incidents, err = utils.RunLogScanJob("healthuser", job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob with workloads failed: %v", err)
+	}
+	if len(incidents) != 1 || incidents[0].Object != "Pod/nightly-123-x" {
+		t.Fatalf("Expected only the CronJob's pending pod, got %+v", incidents)
+	}
+}
@@ -65,7 +65,7 @@ func TestFullLogScanPipelineIntegration(t *testing.T) {
 	}
 	defer func() { utils.RunLogScanJob = orig }()
 
-	go utils.StartScheduler()
+	go utils.StartScheduler(nil)
 	time.Sleep(100 * time.Millisecond) // Give the scheduler goroutine time to start
 
 	// Poll for up to 5 seconds for the incident to appear
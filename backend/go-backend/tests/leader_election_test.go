@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testLeaderConfig(identity string) utils.LeaderElectionConfig {
+	return utils.LeaderElectionConfig{
+		LeaseName:      "test-scheduler",
+		LeaseNamespace: "default",
+		Identity:       identity,
+		LeaseDuration:  1 * time.Second,
+		RenewDeadline:  500 * time.Millisecond,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+}
+
+func TestLeaderElectionSingleLeaderAndFailover(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var leading int32
+
+	onLead := func(ctx context.Context) {
+		atomic.AddInt32(&leading, 1)
+		<-ctx.Done()
+		atomic.AddInt32(&leading, -1)
+	}
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	a := utils.NewLeaderElector(client, testLeaderConfig("replica-a"), onLead)
+	b := utils.NewLeaderElector(client, testLeaderConfig("replica-b"), onLead)
+	go func() { _ = a.Run(ctxA) }()
+	go func() { _ = b.Run(ctxB) }()
+
+	waitFor(t, 3*time.Second, func() bool { return a.IsLeader() || b.IsLeader() })
+	time.Sleep(300 * time.Millisecond)
+	if a.IsLeader() && b.IsLeader() {
+		t.Fatalf("Both replicas claim leadership")
+	}
+	if n := atomic.LoadInt32(&leading); n != 1 {
+		t.Fatalf("Expected exactly one running scheduler, got %d", n)
+	}
+
+	// Stop whichever replica leads and expect the other to take over
+	current, standby := a, b
+	cancelCurrent := cancelA
+	if b.IsLeader() {
+		current, standby = b, a
+		cancelCurrent = cancelB
+	}
+	cancelCurrent()
+	waitFor(t, 3*time.Second, func() bool { return standby.IsLeader() })
+	if current.IsLeader() {
+		t.Fatalf("Stopped replica %s still claims leadership", current.Identity())
+	}
+	if standby.Leader() != standby.Identity() {
+		t.Fatalf("Expected leader %s, got %s", standby.Identity(), standby.Leader())
+	}
+	cancelA()
+}
+
+func TestLeaderPublishesItsScheduler(t *testing.T) {
+	utils.ResetSchedulerForTest()
+	utils.ClearJobs()
+	utils.ClearIncidents()
+	utils.IncidentsFile = "test_incidents_leader.json"
+	defer func() {
+		utils.ClearJobs()
+		utils.ClearIncidents()
+		if err := os.Remove(utils.IncidentsFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove %s: %v", utils.IncidentsFile, err)
+		}
+	}()
+	// The leader's jobs read their cluster through the clients it was given
+	clients := fakeClients{name: "injected", client: fake.NewSimpleClientset(&corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "e1", Namespace: "shop", UID: "uid-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedMount",
+		Message:        "volume not found",
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "db-0"},
+		LastTimestamp:  metav1.Now(),
+	})}
+	utils.SetJobs("leaderuser", []models.Job{{ID: "leader-events-job", Namespace: "shop", Interval: 60, Sources: []string{models.JobSourceEvents}}})
+	defer utils.ForgetJobFindings("leader-events-job")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	elector := utils.NewLeaderElector(fake.NewSimpleClientset(), testLeaderConfig("replica-a"), func(ctx context.Context) {
+		utils.RunSchedulerUntil(ctx, clients)
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = elector.Run(ctx)
+	}()
+
+	waitFor(t, 3*time.Second, func() bool { return elector.IsLeader() && utils.ActiveScheduler() != nil })
+	waitFor(t, 10*time.Second, func() bool { return len(utils.GetRecentIncidents("leaderuser")) > 0 })
+	if inc := utils.GetRecentIncidents("leaderuser")[0]; inc.Cluster != "injected" || inc.Reason != "FailedMount" {
+		t.Fatalf("Expected the event incident from the injected cluster, got %+v", inc)
+	}
+	cancel()
+	<-done
+	waitFor(t, 3*time.Second, func() bool { return utils.ActiveScheduler() == nil })
+}
+
+func TestStoppedSchedulerDropsResultsOfRunningJobs(t *testing.T) {
+	lastRun := time.Now().Add(-time.Hour)
+	store := &memJobStore{jobs: map[string][]models.Job{
+		"leaderuser": {{ID: "slow-job", Interval: 60, LastRun: lastRun}},
+	}}
+	incidentStore := &memIncidentStore{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	executor := funcExecutor(func(userID string, job models.Job) ([]models.Incident, error) {
+		close(started)
+		<-release
+		return []models.Incident{{ID: "late", JobID: job.ID, Status: "Open"}}, nil
+	})
+	s := utils.NewScheduler(store, incidentStore, nil, executor)
+	go s.Run()
+
+	// Leadership is lost while the job runs
+	<-started
+	s.Stop()
+	close(release)
+	time.Sleep(200 * time.Millisecond)
+
+	incidentStore.mu.Lock()
+	defer incidentStore.mu.Unlock()
+	if len(incidentStore.incidents) != 0 {
+		t.Fatalf("Expected a stopped scheduler to store nothing, got %+v", incidentStore.incidents)
+	}
+	if job := store.GetJobs()["leaderuser"][0]; !job.LastRun.Equal(lastRun) {
+		t.Fatalf("Expected the job's last run to be kept for the next leader, got %v", job.LastRun)
+	}
+}
+
+func TestLeadershipStatusWithoutElection(t *testing.T) {
+	utils.ResetSchedulerForTest()
+	enabled, leading, _ := utils.LeadershipStatus()
+	if enabled || !leading {
+		t.Fatalf("Expected election disabled and replica leading, got enabled=%v leading=%v", enabled, leading)
+	}
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("condition not met within %s", timeout)
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+)
+
+func TestParseLogLine(t *testing.T) {
+	cases := []struct {
+		line    string
+		format  string
+		level   string
+		message string
+		logger  string
+		err     string
+		hasTime bool
+	}{
+		{`{"level":"error","msg":"charge failed","ts":1717497000.5,"logger":"payments","error":"card declined"}`, utils.LogFormatJSON, "ERROR", "charge failed", "payments", "card declined", true},
+		{`{"level":50,"time":1717497000123,"msg":"pino error"}`, utils.LogFormatJSON, "ERROR", "pino error", "", "", true},
+		{`{"severity":"WARNING","message":"slow","@timestamp":"2024-06-04T10:30:00Z"}`, utils.LogFormatJSON, "WARN", "slow", "", "", true},
+		{`time="2024-06-04T10:30:00Z" level=warning msg="disk almost full" component=storage err="95% used"`, utils.LogFormatLogfmt, "WARN", "disk almost full", "storage", "95% used", true},
+		{`E0604 10:30:00.123456       1 controller.go:42] sync failed`, utils.LogFormatKlog, "ERROR", "sync failed", "controller.go:42", "", true},
+		{"2024-06-04T10:30:00.000Z\tERROR\tcheckout/handler.go:88\tpayment timeout", utils.LogFormatGo, "ERROR", "payment timeout", "checkout/handler.go:88", "", true},
+		{`2024/06/04 10:30:00 [ERROR] upstream closed`, utils.LogFormatGo, "ERROR", "[ERROR] upstream closed", "", "", true},
+		{`2024-06-04 10:30:00,123 - app.db - CRITICAL - pool exhausted`, utils.LogFormatPython, "CRITICAL", "pool exhausted", "app.db", "", true},
+		{`ERROR:app.db:connection lost`, utils.LogFormatPython, "ERROR", "connection lost", "app.db", "", false},
+		{`2024-06-04 10:30:00.123 [main] ERROR com.example.App - boom`, utils.LogFormatJava, "ERROR", "boom", "com.example.App", "", true},
+		{`2024-06-04T10:30:00.123Z  WARN 1 --- [main] c.e.App : low memory`, utils.LogFormatJava, "WARN", "low memory", "c.e.App", "", true},
+		// Mentions that are not levels
+		{`INFORMATION: cache warmed`, utils.LogFormatText, "", "INFORMATION: cache warmed", "", "", false},
+		{`INFO retried after error`, utils.LogFormatText, "INFO", "INFO retried after error", "", "", false},
+		{`request finished without error`, utils.LogFormatText, "", "request finished without error", "", "", false},
+		{`error: db timeout`, utils.LogFormatText, "ERROR", "error: db timeout", "", "", false},
+	}
+	for _, c := range cases {
+		p := utils.ParseLogLine(c.line)
+		if p.Format != c.format || p.Level != c.level || p.Message != c.message || p.Logger != c.logger || p.Error != c.err {
+			t.Errorf("ParseLogLine(%q) = %+v", c.line, p)
+		}
+		if p.Timestamp.IsZero() == c.hasTime {
+			t.Errorf("ParseLogLine(%q): timestamp %v, want present=%v", c.line, p.Timestamp, c.hasTime)
+		}
+	}
+}
+
+func TestMultilineAssembly(t *testing.T) {
+	lines := []string{
+		`2024-06-04 10:30:00.123 [main] ERROR com.example.App - request failed`,
+		`java.lang.IllegalStateException: boom`,
+		"\tat com.example.App.run(App.java:42)",
+		`Caused by: java.io.IOException: closed`,
+		"\t... 12 more",
+		`INFO:app:next request`,
+		`com.example.PaymentException: declined`,
+		"\tat com.example.Payments.charge(Payments.java:7)",
+		`Traceback (most recent call last):`,
+		`  File "app.py", line 3, in <module>`,
+		`    main()`,
+		`requests.exceptions.HTTPError: 404 Client Error`,
+		`INFO:app:retrying`,
+		`ValueError: bad input`,
+		`panic: runtime error: index out of range`,
+		``,
+		`goroutine 1 [running]:`,
+		`main.main()`,
+		"\t/app/main.go:12 +0x1d",
+		`exit status 2`,
+		`>>> custom start`,
+		`| detail`,
+	}
+	timestamps := make([]time.Time, len(lines))
+	for i := range timestamps {
+		timestamps[i] = time.Date(2024, 6, 4, 10, 30, i, 0, time.UTC)
+	}
+	assembler, err := utils.NewMultilineAssembler([]models.MultilineRule{{Name: "custom", Start: `^>>> `, Continuation: `^\| `, Level: "warn"}})
+	if err != nil {
+		t.Fatalf("NewMultilineAssembler failed: %v", err)
+	}
+	events := assembler.Assemble(timestamps, lines)
+	want := []struct {
+		lines int
+		rule  string
+		level string
+	}{
+		// An exception logged at ERROR stays with the message that logged it
+		{5, "java", "ERROR"},
+		// After an INFO line an exception header starts its own event
+		{1, "", "INFO"},
+		{2, "java", "ERROR"},
+		// A qualified exception ending a traceback stays in it
+		{4, "python", "ERROR"},
+		{1, "", "INFO"},
+		// A bare Python exception line is not a Java exception header
+		{1, "", ""},
+		{6, "go", "CRITICAL"},
+		{2, "custom", "WARN"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if len(e.Lines) != w.lines || e.Rule != w.rule || e.Parse().Level != w.level {
+			t.Errorf("event %d: got %d lines, rule %q, level %q; want %+v", i, len(e.Lines), e.Rule, e.Parse().Level, w)
+		}
+	}
+	if !events[3].Timestamp.Equal(timestamps[8]) {
+		t.Errorf("Expected an event to take its first line's timestamp, got %v", events[3].Timestamp)
+	}
+	if p := events[0].Parse(); p.Message != "request failed" || !strings.Contains(p.Error, "Caused by: java.io.IOException") {
+		t.Errorf("Expected the stack trace in the parsed error field, got %+v", p)
+	}
+
+	if err := utils.ValidateMultilineRules([]models.MultilineRule{{Name: "broken", Continuation: "(["}}); err == nil {
+		t.Errorf("Expected invalid continuation pattern to be rejected")
+	}
+	if err := utils.ValidateMultilineRules([]models.MultilineRule{{Name: "empty", Start: "^x"}}); err == nil {
+		t.Errorf("Expected rule without continuation to be rejected")
+	}
+}
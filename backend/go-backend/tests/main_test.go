@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/go-backend/utils"
+)
+
+// TestMain keeps the log cursors that scanning tests save out of the source tree
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "logscan-tests-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to create temporary directory:", err)
+		os.Exit(1)
+	}
+	utils.CursorsFile = filepath.Join(dir, "cursors_data.json")
+	code := m.Run()
+	if err := os.RemoveAll(dir); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to remove temporary directory:", err)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// memJobStore is an in-memory JobStore for driving a Scheduler directly
+type memJobStore struct {
+	mu   sync.Mutex
+	jobs map[string][]models.Job
+}
+
+func (m *memJobStore) GetJobs() map[string][]models.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := make(map[string][]models.Job, len(m.jobs))
+	for userID, userJobs := range m.jobs {
+		copied[userID] = append([]models.Job(nil), userJobs...)
+	}
+	return copied
+}
+
+func (m *memJobStore) UpdateJobLastRun(userID string, jobIdx int, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[userID][jobIdx].LastRun = t
+}
+
+func (m *memJobStore) SaveJobs() error { return nil }
+
+type memIncidentStore struct {
+	mu        sync.Mutex
+	incidents []models.Incident
+}
+
+func (m *memIncidentStore) AddIncident(userID string, inc models.Incident) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.incidents = append(m.incidents, inc)
+	return nil
+}
+
+type funcExecutor func(userID string, job models.Job) ([]models.Incident, error)
+
+func (f funcExecutor) Run(userID string, job models.Job) ([]models.Incident, error) {
+	return f(userID, job)
+}
+
+// counterValue sums a counter family across series matching the given labels
+func counterValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	total := 0.0
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if want, ok := labels[lp.GetName()]; ok && want != lp.GetValue() {
+					continue metrics
+				}
+			}
+			total += m.GetCounter().GetValue()
+		}
+	}
+	return total
+}
+
+func TestSchedulerRecordsJobMetrics(t *testing.T) {
+	store := &memJobStore{jobs: map[string][]models.Job{
+		"metrics-user": {
+			{ID: "metrics-ok", Interval: 60, LastRun: time.Now().Add(-time.Hour)},
+			{ID: "metrics-fail", Interval: 60, LastRun: time.Now().Add(-time.Hour)},
+		},
+	}}
+	incidentStore := &memIncidentStore{}
+	executor := funcExecutor(func(userID string, job models.Job) ([]models.Incident, error) {
+		if job.ID == "metrics-fail" {
+			return nil, errors.New("scan failed")
+		}
+		return []models.Incident{{ID: "inc-metrics", JobID: job.ID, Severity: "High", Category: `{"category":"Storage"}`}}, nil
+	})
+
+	successBefore := counterValue(t, "scheduler_job_runs_total", map[string]string{"outcome": "success"})
+	failureBefore := counterValue(t, "scheduler_job_runs_total", map[string]string{"outcome": "failure"})
+	incidentsBefore := counterValue(t, "incidents_created_total", map[string]string{"severity": "High", "category": "Storage"})
+
+	s := utils.NewScheduler(store, incidentStore, nil, executor)
+	go s.Run()
+	defer s.Stop()
+
+	waitFor(t, 3*time.Second, func() bool {
+		return counterValue(t, "scheduler_job_runs_total", map[string]string{"outcome": "failure"}) > failureBefore &&
+			counterValue(t, "incidents_created_total", map[string]string{"severity": "High", "category": "Storage"}) > incidentsBefore
+	})
+	if got := counterValue(t, "scheduler_job_runs_total", map[string]string{"outcome": "success"}); got < successBefore+1 {
+		t.Fatalf("Expected a successful run to be recorded, got %v (before %v)", got, successBefore)
+	}
+}
+
+func TestIncidentCategoryName(t *testing.T) {
+	for category, want := range map[string]string{
+		"General":                 "General",
+		`{"category":"Database"}`: "Database",
+		`{"category":{"name":"Network","score":0.8}}`: "Network",
+		`{"category":null}`:                           "unknown",
+		`{"category":`:                                "unknown",
+		"":                                            "unknown",
+	} {
+		if got := utils.IncidentCategoryName(category); got != want {
+			t.Errorf("IncidentCategoryName(%q) = %q, want %q", category, got, want)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package tests
 import (
 	"backend/go-backend/models"
 	"backend/go-backend/utils"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -104,3 +107,58 @@ func TestIncidentPersistence(t *testing.T) {
 		t.Fatalf("GetRecentIncidents after reload failed: got %+v", incidents)
 	}
 }
+
+func TestCursorPersistence(t *testing.T) {
+	cursorsFile := utils.CursorsFile
+	utils.CursorsFile = filepath.Join(t.TempDir(), "cursors_data.json")
+	defer func() { utils.CursorsFile = cursorsFile }()
+	utils.ClearCursors()
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	key := utils.CursorKey("api-0", "app")
+	err := utils.SetJobCursors("job1", map[string]models.LogCursor{
+		key: {ContainerID: "containerd://abc", LastTimestamp: ts},
+	})
+	if err != nil {
+		t.Fatalf("SetJobCursors failed: %v", err)
+	}
+
+	utils.ClearCursors()
+	if err := utils.LoadCursors(); err != nil {
+		t.Fatalf("LoadCursors failed: %v", err)
+	}
+	cursor, ok := utils.GetJobCursors("job1")[key]
+	if !ok || cursor.ContainerID != "containerd://abc" || !cursor.LastTimestamp.Equal(ts) {
+		t.Fatalf("GetJobCursors after reload failed: got %+v", cursor)
+	}
+	if len(utils.GetJobCursors("other-job")) != 0 {
+		t.Fatalf("Expected no cursors for unknown job")
+	}
+
+	// Jobs save their cursors concurrently; the file must end up with all of them
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jobCursors := map[string]models.LogCursor{key: {ContainerID: fmt.Sprintf("containerd://%d", i), LastTimestamp: ts}}
+			if err := utils.SetJobCursors(fmt.Sprintf("job-%d", i), jobCursors); err != nil {
+				t.Errorf("SetJobCursors failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	utils.ClearCursors()
+	if err := utils.LoadCursors(); err != nil {
+		t.Fatalf("LoadCursors after concurrent saves failed: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if cursor := utils.GetJobCursors(fmt.Sprintf("job-%d", i))[key]; cursor.ContainerID != fmt.Sprintf("containerd://%d", i) {
+			t.Errorf("Expected job-%d's cursor to be saved, got %+v", i, cursor)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(utils.CursorsFile))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the cursors file to be left, got %v %v", entries, err)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodInventoryDescribesAndFiltersPods(t *testing.T) {
+	isController := true
+	controlledBy := func(kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, UID: "u", Controller: &isController}}
+	}
+	created := metav1.NewTime(time.Now().Add(-time.Hour))
+	clients := fakeClients{name: "fake", client: fake.NewSimpleClientset(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "shop", OwnerReferences: controlledBy("Deployment", "web")}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-abc-1", Namespace: "shop", Labels: map[string]string{"app": "web"},
+				OwnerReferences: controlledBy("ReplicaSet", "web-abc"), CreationTimestamp: created,
+			},
+			Spec: corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{
+				{Name: "app", Image: "shop/web:1.4"},
+				{Name: "proxy", Image: "envoy:1.29"},
+			}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", RestartCount: 3, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+					{Name: "proxy", Ready: true, RestartCount: 1, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "shop", Labels: map[string]string{"app": "db"}, OwnerReferences: controlledBy("StatefulSet", "db")},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "postgres", Image: "postgres:16"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	)}
+	handler := handlers.HandleK8sPods(&services.DefaultK8sService{Clients: clients})
+	list := func(query string) ([]models.PodInfo, int) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/k8s-pods?cluster=fake&namespace=shop"+query, nil))
+		if w.Code != http.StatusOK {
+			return nil, w.Code
+		}
+		var resp struct {
+			Pods []models.PodInfo `json:"pods"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("invalid pods response: %v", err)
+		}
+		return resp.Pods, w.Code
+	}
+
+	pods, _ := list("")
+	if len(pods) != 2 || pods[0].Name != "db-0" || pods[1].Name != "web-abc-1" {
+		t.Fatalf("Expected both pods sorted by name, got %+v", pods)
+	}
+	web := pods[1]
+	if web.Phase != "Running" || web.Ready || web.ReadyContainers != 1 || web.Restarts != 4 ||
+		web.Owner != "Deployment/web" || web.Node != "node-1" || web.Labels["app"] != "web" {
+		t.Errorf("Unexpected pod summary: %+v", web)
+	}
+	if web.AgeSeconds < 3500 || web.AgeSeconds > 3700 {
+		t.Errorf("Expected the pod to be about an hour old, got %ds", web.AgeSeconds)
+	}
+	if len(web.Containers) != 2 || web.Containers[0] != (models.ContainerInfo{
+		Name: "app", Image: "shop/web:1.4", RestartCount: 3, State: "waiting", Reason: "CrashLoopBackOff",
+	}) {
+		t.Errorf("Unexpected containers: %+v", web.Containers)
+	}
+	if db := pods[0]; db.Owner != "StatefulSet/db" || len(db.Containers) != 1 || db.Containers[0].State != "unknown" {
+		t.Errorf("Unexpected pod summary: %+v", db)
+	}
+
+	for query, want := range map[string]string{
+		"&label_selector=app%3Ddb":           "db-0",
+		"&owner=Deployment/web":              "web-abc-1",
+		"&owner=web":                         "web-abc-1",
+		"&owner=statefulset/db":              "db-0",
+		"&label_selector=app%3Dweb&owner=db": "",
+	} {
+		pods, code := list(query)
+		if code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", query, code)
+		}
+		if (want == "" && len(pods) != 0) || (want != "" && (len(pods) != 1 || pods[0].Name != want)) {
+			t.Errorf("%s: expected %q, got %+v", query, want, pods)
+		}
+	}
+	for _, query := range []string{"&owner=Deployment/", "&label_selector=app%3D%3D%3D"} {
+		if _, code := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, code)
+		}
+	}
+}
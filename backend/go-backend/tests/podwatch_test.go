@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/utils"
+)
+
+func TestWatchModeFollowsContainersAsTheyStart(t *testing.T) {
+	var mu sync.Mutex
+	var logQueries []string
+	done := make(chan struct{})
+	defer close(done)
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pods") && r.URL.Query().Get("watch") == "true":
+			// Announce a pod that starts after the watch began, then keep the watch open
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"type":"ADDED","object":{"kind":"Pod","apiVersion":"v1","metadata":{"name":"web-2","namespace":"shop","resourceVersion":"2"},`+
+				`"spec":{"containers":[{"name":"app"}]},"status":{"phase":"Running","containerStatuses":[{"name":"app","containerID":"containerd://web-2",`+
+				`"state":{"running":{"startedAt":%q}}}]}}}`+"\n", time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		case strings.HasSuffix(r.URL.Path, "/pods"):
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":"1"},"items":[]}`)
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			mu.Lock()
+			logQueries = append(logQueries, r.URL.RawQuery)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "%s INFO starting\n%s ERROR connection refused\n",
+				podLogTime.Format(time.RFC3339Nano), podLogTime.Format(time.RFC3339Nano))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	userID := "watchuser"
+	store := &memJobStore{jobs: map[string][]models.Job{
+		userID: {{
+			// Not due, so only the watch can produce incidents
+			ID: "watch-job", Namespace: "shop", Mode: models.JobModeWatch, Interval: 3600,
+			LastRun: time.Now(), LogLevels: []string{"ERROR"},
+		}},
+	}}
+	incidentStore := &memIncidentStore{}
+	executor := funcExecutor(func(userID string, job models.Job) ([]models.Incident, error) {
+		t.Errorf("Watch-mode job must not be run by the interval scheduler")
+		return nil, nil
+	})
+	s := utils.NewScheduler(store, incidentStore, nil, executor)
+	go s.Run()
+	defer s.Stop()
+
+	waitFor(t, 5*time.Second, func() bool {
+		incidentStore.mu.Lock()
+		defer incidentStore.mu.Unlock()
+		return len(incidentStore.incidents) > 0
+	})
+	incidentStore.mu.Lock()
+	inc := incidentStore.incidents[0]
+	count := len(incidentStore.incidents)
+	incidentStore.mu.Unlock()
+	if count != 1 || inc.Pod != "web-2" || inc.Container != "app" || inc.LogLine != "ERROR connection refused" || inc.JobID != "watch-job" {
+		t.Fatalf("Expected one incident for the followed ERROR line, got %d: %+v", count, inc)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logQueries) != 1 || !strings.Contains(logQueries[0], "follow=true") || strings.Contains(logQueries[0], "tailLines") {
+		t.Fatalf("Expected the new container's whole log to be followed, got %q", logQueries)
+	}
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/testhelpers"
+	"backend/go-backend/utils"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestJobPreflightChecksNamespaceAndAccess(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "restricted"}},
+	)
+	// The backend may do anything in shop, but only list and watch in restricted
+	var reviewed []string
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		resource := attrs.Resource
+		if attrs.Group != "" {
+			resource += "." + attrs.Group
+		}
+		reviewed = append(reviewed, attrs.Namespace+": "+attrs.Verb+" "+resource+"/"+attrs.Subresource)
+		review.Status.Allowed = attrs.Namespace == "shop" || attrs.Verb != "get"
+		return true, review, nil
+	})
+	utils.ClearJobs()
+	utils.ClearTemplates()
+	utils.JobsFile = "test_jobs_preflight.json"
+	utils.TemplatesFile = "test_templates_preflight.json"
+	defer func() {
+		for _, f := range []string{utils.JobsFile, utils.TemplatesFile} {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				t.Errorf("failed to remove %s: %v", f, err)
+			}
+		}
+	}()
+	svc := &services.DefaultJobService{Clients: fakeClients{name: "fake", client: client}}
+	userID := "preflightuser"
+
+	job, err := svc.CreateLogScanJob(userID, services.CreateJobRequest{Name: "shop", Namespace: "shop", Interval: 60})
+	if err != nil {
+		t.Fatalf("Expected a job in a readable namespace to be created, got %v", err)
+	}
+	if strings.Join(reviewed, ", ") != "shop: list pods/, shop: get pods/log" {
+		t.Fatalf("Expected the logs source's permissions to be reviewed, got %q", reviewed)
+	}
+
+	for name, tc := range map[string]struct {
+		req  services.CreateJobRequest
+		want string
+	}{
+		"missing namespace": {services.CreateJobRequest{Namespace: "gone", Interval: 60}, `namespace "gone" does not exist in cluster "fake"`},
+		"unknown cluster":   {services.CreateJobRequest{Namespace: "shop", Cluster: "other", Interval: 60}, "unknown cluster: other"},
+		"denied logs":       {services.CreateJobRequest{Namespace: "restricted", Interval: 60}, `not allowed to get pods/log in namespace "restricted"`},
+	} {
+		_, err := svc.CreateLogScanJob(userID, tc.req)
+		if !errors.Is(err, services.ErrJobPreflight) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected a preflight error containing %q, got %v", name, tc.want, err)
+		}
+	}
+	// Events and health only need to list pods and Deployments and list and watch events
+	reviewed = nil
+	if _, err := svc.CreateLogScanJob(userID, services.CreateJobRequest{
+		Namespace: "restricted", Interval: 60, Sources: []string{models.JobSourceEvents, models.JobSourceHealth},
+	}); err != nil {
+		t.Fatalf("Expected an events and health job to pass preflight, got %v", err)
+	}
+	if got := strings.Join(reviewed, ", "); got != "restricted: list pods/, restricted: list events/, restricted: watch events/, restricted: list deployments.apps/" {
+		t.Fatalf("Expected the events and health permissions to be reviewed, got %q", got)
+	}
+
+	// Updates are checked too, and rejected with the reason
+	body, _ := json.Marshal(services.UpdateJobRequest{Name: "shop", Namespace: "restricted", Interval: 60})
+	r := httptest.NewRequest("PUT", "/api/log-scan-jobs/"+job.ID, bytes.NewReader(body))
+	r = testhelpers.WithUser(r, userID)
+	w := httptest.NewRecorder()
+	handlers.HandleUpdateLogScanJob(svc)(w, r)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "get pods/log") {
+		t.Fatalf("Expected 400 naming the missing permission, got %d %q", w.Code, w.Body.String())
+	}
+
+	// Watch mode and workload targets need more than a plain logs job
+	reviewed = nil
+	if _, err := svc.CreateLogScanJob(userID, services.CreateJobRequest{
+		Namespace: "shop", Interval: 60, Mode: models.JobModeWatch,
+		Workloads: []models.WorkloadRef{{Kind: models.WorkloadDeployment, Name: "web"}, {Kind: models.WorkloadCronJob, Name: "nightly"}},
+	}); err != nil {
+		t.Fatalf("Expected a watch job in shop to be created, got %v", err)
+	}
+	if got := strings.Join(reviewed, ", "); got != "shop: list pods/, shop: get pods/log, shop: watch pods/, shop: list replicasets.apps/, shop: list jobs.batch/" {
+		t.Fatalf("Expected the watch and workload permissions to be reviewed, got %q", got)
+	}
+
+	// Clones and template jobs are checked like created ones
+	_, err = svc.CloneLogScanJob(userID, job.ID, services.CloneJobRequest{Namespace: "restricted"})
+	if !errors.Is(err, services.ErrJobPreflight) {
+		t.Errorf("Expected a clone into restricted to fail preflight, got %v", err)
+	}
+	if _, err := svc.CloneLogScanJob(userID, job.ID, services.CloneJobRequest{Cluster: "other"}); !errors.Is(err, services.ErrJobPreflight) {
+		t.Errorf("Expected a clone into an unknown cluster to fail preflight, got %v", err)
+	}
+	templates := &services.DefaultTemplateService{Clients: svc.Clients}
+	tmpl, err := templates.CreateTemplate(userID, services.TemplateRequest{Name: "errors", Namespace: "{{namespace}}", Interval: 60})
+	if err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+	before := len(utils.GetJobs(userID))
+	_, err = templates.InstantiateTemplate(userID, tmpl.ID, services.InstantiateTemplateRequest{
+		Params: []map[string]string{{"namespace": "shop"}, {"namespace": "restricted"}},
+	})
+	if !errors.Is(err, services.ErrJobPreflight) || !strings.Contains(err.Error(), `namespace "restricted"`) {
+		t.Errorf("Expected instantiating into restricted to fail preflight, got %v", err)
+	}
+	if after := len(utils.GetJobs(userID)); after != before {
+		t.Errorf("Expected no job to be created by a failed instantiate, got %d jobs instead of %d", after, before)
+	}
+}
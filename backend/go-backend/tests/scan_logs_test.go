@@ -0,0 +1,395 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+)
+
+// podLogTime is the runtime timestamp podLogServer gives every line
+var podLogTime = time.Date(2024, 6, 4, 10, 30, 0, 0, time.UTC)
+
+// podLogServer serves pod lists and logs for the given namespace -> pod -> log lines.
+// Pods run one "app" container on node-1, and lines carry runtime timestamps.
+func podLogServer(logs map[string]map[string][]string, queries *[]string, mu *sync.Mutex) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		// api/v1/namespaces/{ns}/pods[/{pod}/log]
+		if len(parts) < 5 || parts[4] != "pods" {
+			http.NotFound(w, r)
+			return
+		}
+		ns := parts[3]
+		if len(parts) == 5 {
+			var items []string
+			for pod := range logs[ns] {
+				items = append(items, fmt.Sprintf(`{"metadata":{"name":%q,"namespace":%q},"spec":{"nodeName":"node-1","containers":[{"name":"app"}]},"status":{"containerStatuses":[{"name":"app","containerID":"containerd://app"}]}}`, pod, ns))
+			}
+			fmt.Fprintf(w, `{"kind":"PodList","apiVersion":"v1","items":[%s]}`, strings.Join(items, ","))
+			return
+		}
+		mu.Lock()
+		*queries = append(*queries, r.URL.RawQuery)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain")
+		for _, line := range logs[ns][parts[5]] {
+			fmt.Fprintf(w, "%s %s\n", podLogTime.Format(time.RFC3339Nano), line)
+		}
+	})
+}
+
+func TestScanLogsHonorsRequestOptions(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	useTestCluster(t, podLogServer(map[string]map[string][]string{
+		"frontend": {"web-1": {"ERROR upstream timeout", "ERROR bad request", "INFO ok", "ERROR upstream TIMEOUT again"}},
+		"backend":  {"api-1": {"error: db timeout", "WARN slow query"}},
+	}, &queries, &mu))
+
+	scan := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		r := httptest.NewRequest("POST", "/scan-k8s-logs", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handlers.HandleScanK8sLogs(&services.DefaultK8sService{})(w, r)
+		return w
+	}
+
+	w := scan(map[string]interface{}{
+		"namespaces":         []string{"frontend", "backend"},
+		"log_levels":         []string{"error"},
+		"search_patterns":    []string{"time(out)?"},
+		"time_range_minutes": 15,
+		"max_lines_per_pod":  1,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Scan failed: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results []models.LogMatch `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal scan response: %v", err)
+	}
+	var lines []string
+	for _, r := range resp.Results {
+		lines = append(lines, r.Line)
+		if r.Container != "app" || r.Node != "node-1" || r.Cluster != "test" || !r.Timestamp.Equal(podLogTime) {
+			t.Errorf("Expected provenance on every result, got %+v", r)
+		}
+		if r.Pod == "api-1" && (r.Namespace != "backend" || r.Level != "ERROR" || r.Rule != "level:ERROR,pattern:time(out)?") {
+			t.Errorf("Unexpected metadata for backend line: %+v", r)
+		}
+	}
+	if len(lines) != 2 || !containsLine(lines, "ERROR upstream timeout") || !containsLine(lines, "error: db timeout") {
+		t.Fatalf("Expected one matching line per pod across both namespaces, got %q", lines)
+	}
+	mu.Lock()
+	for _, q := range queries {
+		// The line cap applies to matches, so the whole range is read
+		if !strings.Contains(q, "sinceSeconds=900") || strings.Contains(q, "tailLines") {
+			t.Errorf("Expected the time range without a line cap in log query, got %q", q)
+		}
+	}
+	queries = nil
+	mu.Unlock()
+	if w := scan(map[string]interface{}{"namespaces": []string{"frontend"}, "max_lines_per_pod": 2}); w.Code != http.StatusOK {
+		t.Fatalf("Scan failed: %d %s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	if len(queries) != 1 || !strings.Contains(queries[0], "tailLines=2") || strings.Contains(queries[0], "sinceSeconds") {
+		t.Errorf("Expected only the last lines to be read without a time range, got %q", queries)
+	}
+	mu.Unlock()
+
+	invalid := []map[string]interface{}{
+		{"namespaces": []string{"frontend"}, "search_patterns": []string{"(unclosed"}},
+		{"namespaces": []string{"frontend"}, "max_lines_per_pod": 5000},
+		{"namespaces": []string{"frontend"}, "time_range_minutes": 2000},
+		{"namespaces": []string{"frontend", ""}},
+	}
+	for _, payload := range invalid {
+		if w := scan(payload); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %v, got %d %s", payload, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestScanLogsReadsPreviousContainerLogs(t *testing.T) {
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/pods") {
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","items":[{"metadata":{"name":"worker-1","namespace":"jobs"},`+
+				`"spec":{"containers":[{"name":"app"}]},"status":{"containerStatuses":[{"name":"app","containerID":"containerd://new",`+
+				`"restartCount":3,"lastState":{"terminated":{"reason":"OOMKilled","exitCode":137,"containerID":"containerd://old"}}}]}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		line := "ERROR starting worker again"
+		if r.URL.Query().Get("previous") == "true" {
+			line = "ERROR cache allocation failed"
+		}
+		fmt.Fprintf(w, "%s %s\n", podLogTime.Format(time.RFC3339Nano), line)
+	}))
+
+	results, _, err := (&services.DefaultK8sService{}).ScanLogs(services.ScanLogsRequest{
+		Namespaces: []string{"jobs"},
+		LogLevels:  []string{"ERROR"},
+	})
+	if err != nil {
+		t.Fatalf("ScanLogs failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected a match from each container instance, got %+v", results)
+	}
+	for _, r := range results {
+		if r.Restart == nil || r.Restart.RestartCount != 3 || r.Restart.TerminationReason != "OOMKilled" ||
+			r.Restart.ExitCode == nil || *r.Restart.ExitCode != 137 {
+			t.Errorf("Expected restart details on %+v", r)
+		}
+		if r.Previous != (r.Line == "ERROR cache allocation failed") {
+			t.Errorf("Expected only the terminated instance's line to be marked previous, got %+v", r)
+		}
+	}
+}
+
+func TestScanLogsReportsUnreadableContainers(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	logs := podLogServer(map[string]map[string][]string{
+		"shop": {"web-1": {"ERROR upstream timeout"}, "locked-1": {"ERROR hidden"}},
+	}, &queries, &mu)
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/locked-1/log") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403,"message":"pods \"locked-1\" is forbidden"}`)
+			return
+		}
+		logs.ServeHTTP(w, r)
+	}))
+
+	body, _ := json.Marshal(map[string]interface{}{"namespaces": []string{"shop"}, "log_levels": []string{"ERROR"}})
+	w := httptest.NewRecorder()
+	handlers.HandleScanK8sLogs(&services.DefaultK8sService{})(w, httptest.NewRequest("POST", "/scan-k8s-logs", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Scan failed: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results []models.LogMatch  `json:"results"`
+		Errors  []models.ScanError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal scan response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Pod != "web-1" {
+		t.Fatalf("Expected the readable pod's match, got %+v", resp.Results)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Namespace != "shop" || resp.Errors[0].Pod != "locked-1" ||
+		resp.Errors[0].Container != "app" || !strings.Contains(resp.Errors[0].Error, "forbidden") {
+		t.Fatalf("Expected the forbidden container to be reported, got %+v", resp.Errors)
+	}
+}
+
+func TestScanLogsReportsLogsTruncatedByTheByteLimit(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	busy := []string{"ERROR first failure"}
+	for i := 0; i < 5000; i++ {
+		busy = append(busy, "INFO "+strings.Repeat("x", 1024))
+	}
+	busy = append(busy, "ERROR newest failure")
+	logs := podLogServer(map[string]map[string][]string{
+		"shop": {"busy-1": busy, "quiet-1": {"ERROR quiet failure"}},
+	}, &queries, &mu)
+	// Like the API server, cut the log off after limitBytes
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		logs.ServeHTTP(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		body := rec.Body.Bytes()
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limitBytes")); err == nil && limit < len(body) {
+			body = body[:limit]
+		}
+		w.Write(body)
+	}))
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"namespaces": []string{"shop"}, "log_levels": []string{"ERROR"}, "time_range_minutes": 15,
+	})
+	w := httptest.NewRecorder()
+	handlers.HandleScanK8sLogs(&services.DefaultK8sService{})(w, httptest.NewRequest("POST", "/scan-k8s-logs", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Scan failed: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results []models.LogMatch  `json:"results"`
+		Errors  []models.ScanError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal scan response: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("Expected the matches read before the limit, got %+v", resp.Results)
+	}
+	if len(resp.Errors) != 1 || !resp.Errors[0].Truncated || resp.Errors[0].Pod != "busy-1" ||
+		!strings.Contains(resp.Errors[0].Error, "were not read") {
+		t.Fatalf("Expected the busy container to be reported as truncated, got %+v", resp.Errors)
+	}
+}
+
+func TestJobLogCollectionListsPodsOnceAndReportsContainerErrors(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	podLists := 0
+	logs := podLogServer(map[string]map[string][]string{
+		"shop": {
+			"web-1": {"ERROR upstream timeout"},
+			"web-2": {"ERROR disk full"},
+			"web-3": {"ERROR never read"},
+		},
+	}, &queries, &mu)
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/pods"):
+			mu.Lock()
+			podLists++
+			mu.Unlock()
+		case strings.HasSuffix(r.URL.Path, "/web-3/log"):
+			http.Error(w, "container log unavailable", http.StatusInternalServerError)
+			return
+		}
+		logs.ServeHTTP(w, r)
+	}))
+
+	preview, err := utils.PreviewLogScanJob(nil, models.Job{
+		Namespace: "shop",
+		Pods:      []string{"web-1", "web-2", "web-3", "web-gone"},
+		LogLevels: []string{"ERROR"},
+	}, false)
+	if err != nil {
+		t.Fatalf("PreviewLogScanJob failed: %v", err)
+	}
+	var lines []string
+	for _, m := range preview.Matches {
+		lines = append(lines, m.Pod+": "+m.Line)
+	}
+	if len(lines) != 2 || !containsLine(lines, "web-1: ERROR upstream timeout") || !containsLine(lines, "web-2: ERROR disk full") {
+		t.Fatalf("Expected the lines of the readable containers, got %q", lines)
+	}
+	errs := make(map[string]string)
+	for _, e := range preview.Errors {
+		errs[e.Pod+"/"+e.Container] = e.Error
+	}
+	if len(errs) != 2 || errs["web-gone/"] != "pod not found" || !strings.Contains(errs["web-3/app"], "container log unavailable") {
+		t.Fatalf("Expected the missing pod and the failed container to be reported, got %+v", preview.Errors)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if podLists != 1 {
+		t.Fatalf("Expected the namespace to be listed once, got %d listings", podLists)
+	}
+	for _, q := range queries {
+		if !strings.Contains(q, "limitBytes=") {
+			t.Errorf("Expected log reads to be capped, got %q", q)
+		}
+	}
+}
+
+func TestScansApplyContainerFilters(t *testing.T) {
+	var mu sync.Mutex
+	var read []string
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/pods") {
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","items":[{"metadata":{"name":"web-1","namespace":"shop"},`+
+				`"spec":{"initContainers":[{"name":"migrate"}],"containers":[{"name":"app"},{"name":"istio-proxy"}],"ephemeralContainers":[{"name":"debugger"}]},`+
+				`"status":{"initContainerStatuses":[{"name":"migrate","containerID":"containerd://migrate"}],`+
+				`"containerStatuses":[{"name":"app","containerID":"containerd://app"},{"name":"istio-proxy","containerID":"containerd://proxy"}],`+
+				`"ephemeralContainerStatuses":[{"name":"debugger","containerID":"containerd://debugger"}]}}]}`)
+			return
+		}
+		container := r.URL.Query().Get("container")
+		mu.Lock()
+		read = append(read, container)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s ERROR failure in %s\n", podLogTime.Format(time.RFC3339Nano), container)
+	}))
+	containersOf := func(matches []models.LogMatch) []string {
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.Container)
+		}
+		return names
+	}
+
+	results, _, err := (&services.DefaultK8sService{}).ScanLogs(services.ScanLogsRequest{
+		Namespaces: []string{"shop"},
+		LogLevels:  []string{"ERROR"},
+		Containers: &models.ContainerFilter{Exclude: []string{"istio-*"}, Init: true},
+	})
+	if err != nil {
+		t.Fatalf("ScanLogs failed: %v", err)
+	}
+	if got := strings.Join(containersOf(results), ","); got != "app,migrate" {
+		t.Fatalf("Expected the app and init containers, got %q", got)
+	}
+
+	mu.Lock()
+	read = nil
+	mu.Unlock()
+	preview, err := utils.PreviewLogScanJob(nil, models.Job{
+		Namespace: "shop", LogLevels: []string{"ERROR"},
+		Containers: &models.ContainerFilter{Include: []string{"debug*", "app"}, Ephemeral: true},
+	}, false)
+	if err != nil {
+		t.Fatalf("PreviewLogScanJob failed: %v", err)
+	}
+	var previewed []string
+	for _, m := range preview.Matches {
+		previewed = append(previewed, m.Container)
+	}
+	if got := strings.Join(previewed, ","); got != "app,debugger" {
+		t.Fatalf("Expected the app and ephemeral containers, got %q", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(read) != 2 {
+		t.Fatalf("Expected only the selected containers' logs to be read, got %q", read)
+	}
+
+	_, _, err = (&services.DefaultK8sService{}).ScanLogs(services.ScanLogsRequest{
+		Namespaces: []string{"shop"},
+		Containers: &models.ContainerFilter{Exclude: []string{"istio-["}},
+	})
+	if !errors.Is(err, services.ErrInvalidContainerFilter) {
+		t.Fatalf("Expected ErrInvalidContainerFilter for a malformed pattern, got %v", err)
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
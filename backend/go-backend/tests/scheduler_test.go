@@ -59,7 +59,7 @@ func TestSchedulerRunsJobsAtInterval(t *testing.T) {
 	if len(jobsBefore) > 0 {
 		t.Logf("Before scheduler: LastRun = %v", jobsBefore[0].LastRun)
 	}
-	go utils.StartScheduler()
+	go utils.StartScheduler(nil)
 	time.Sleep(100 * time.Millisecond) // Give the scheduler goroutine time to start
 	// Wait for a few intervals (increase to 5s for robustness)
 	time.Sleep(5000 * time.Millisecond)
@@ -109,7 +109,7 @@ func TestSchedulerConcurrencyLimit(t *testing.T) {
 	}
 	defer func() { utils.RunLogScanJob = orig }()
 
-	go utils.StartScheduler()
+	go utils.StartScheduler(nil)
 	// Wait a bit for jobs to start
 	time.Sleep(500 * time.Millisecond)
 	// Only MaxConcurrentJobs should be running (blocked)
@@ -160,7 +160,7 @@ func TestJobRunsImmediatelyAfterCreation(t *testing.T) {
 	if len(jobsBefore) > 0 {
 		t.Logf("Before scheduler: LastRun = %v", jobsBefore[0].LastRun)
 	}
-	go utils.StartScheduler()
+	go utils.StartScheduler(nil)
 	time.Sleep(100 * time.Millisecond) // Give the scheduler goroutine time to start
 	// Wait longer for robustness
 	time.Sleep(2500 * time.Millisecond)
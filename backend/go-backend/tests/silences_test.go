@@ -0,0 +1,260 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	testhelpers "backend/go-backend/testhelpers"
+	"backend/go-backend/utils"
+)
+
+func TestSilenceMatchingAndRecurrence(t *testing.T) {
+	inc := models.Incident{JobID: "job1", Service: "payments", Pod: "api-7d9f", Severity: "High", LogLine: "ERROR connection refused"}
+
+	silence := models.Silence{Namespace: "payments", PodPattern: "api-*", LinePattern: "connection (refused|reset)"}
+	if !utils.SilenceMatches(silence, inc) {
+		t.Fatalf("Expected silence to match incident")
+	}
+	silence.Severity = "critical"
+	if utils.SilenceMatches(silence, inc) {
+		t.Fatalf("Expected severity mismatch to prevent a match")
+	}
+
+	// Every Tuesday 22:00-23:30 UTC
+	window := models.Silence{
+		Namespace:  "payments",
+		Recurrence: &models.Recurrence{Weekdays: []string{"Tue"}, StartTime: "22:00", DurationMinutes: 90},
+	}
+	tuesday := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC) // a Tuesday
+	cases := []struct {
+		at     time.Time
+		active bool
+	}{
+		{tuesday.Add(22*time.Hour + 30*time.Minute), true},
+		{tuesday.Add(23*time.Hour + 45*time.Minute), false},
+		{tuesday.Add(21 * time.Hour), false},
+		{tuesday.Add(24*time.Hour + 22*time.Hour + 30*time.Minute), false}, // Wednesday
+	}
+	for _, c := range cases {
+		if got := utils.SilenceActiveAt(window, c.at); got != c.active {
+			t.Errorf("SilenceActiveAt(%v) = %v, want %v", c.at, got, c.active)
+		}
+	}
+}
+
+func TestSchedulerRecordsSuppressedIncidents(t *testing.T) {
+	utils.SilencesFile = "test_silences_data.json"
+	defer func() {
+		utils.ClearSilences()
+		if err := os.Remove(utils.SilencesFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove silences file: %v", err)
+		}
+	}()
+	userID := "silenceduser"
+	err := utils.SetSilences(userID, []models.Silence{{
+		ID:          "sil-1",
+		LinePattern: "expected during deploy",
+		StartsAt:    time.Now().Add(-time.Hour),
+		EndsAt:      time.Now().Add(time.Hour),
+	}})
+	if err != nil {
+		t.Fatalf("SetSilences failed: %v", err)
+	}
+
+	store := &memJobStore{jobs: map[string][]models.Job{
+		userID: {{ID: "silenced-job", Interval: 60, LastRun: time.Now().Add(-time.Hour)}},
+	}}
+	incidentStore := &memIncidentStore{}
+	executor := funcExecutor(func(userID string, job models.Job) ([]models.Incident, error) {
+		return []models.Incident{
+			{ID: "inc-a", JobID: job.ID, Status: "Open", LogLine: "ERROR expected during deploy"},
+			{ID: "inc-b", JobID: job.ID, Status: "Open", LogLine: "ERROR disk full"},
+		}, nil
+	})
+	s := utils.NewScheduler(store, incidentStore, nil, executor)
+	go s.Run()
+	defer s.Stop()
+
+	waitFor(t, 3*time.Second, func() bool {
+		incidentStore.mu.Lock()
+		defer incidentStore.mu.Unlock()
+		return len(incidentStore.incidents) >= 2
+	})
+	incidentStore.mu.Lock()
+	defer incidentStore.mu.Unlock()
+	for _, inc := range incidentStore.incidents[:2] {
+		switch inc.ID {
+		case "inc-a":
+			if inc.Status != "Suppressed" || inc.SilenceID != "sil-1" {
+				t.Errorf("Expected inc-a to be suppressed by sil-1, got %+v", inc)
+			}
+		case "inc-b":
+			if inc.Status != "Open" || inc.SilenceID != "" {
+				t.Errorf("Expected inc-b to stay open, got %+v", inc)
+			}
+		}
+	}
+}
+
+func TestSilencedLogLinesAreNotAnalysed(t *testing.T) {
+	var mu sync.Mutex
+	var queries, analysed []string
+	useTestCluster(t, podLogServer(map[string]map[string][]string{
+		"shop": {"web-1": {"ERROR expected during deploy", "ERROR disk full"}},
+	}, &queries, &mu))
+	analyzer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Logs []string `json:"logs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid analyzer request: %v", err)
+		}
+		mu.Lock()
+		analysed = append(analysed, req.Logs...)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"category":"storage"}`)
+	}))
+	defer analyzer.Close()
+	t.Setenv("LOG_ANALYZER_URL", analyzer.URL)
+	utils.SilencesFile = "test_silences_analysis.json"
+	defer func() {
+		utils.ClearSilences()
+		if err := os.Remove(utils.SilencesFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove silences file: %v", err)
+		}
+	}()
+	userID := "silencedanalysisuser"
+	if err := utils.SetSilences(userID, []models.Silence{{
+		ID: "sil-deploy", LinePattern: "expected during deploy", EndsAt: time.Now().Add(time.Hour),
+	}}); err != nil {
+		t.Fatalf("SetSilences failed: %v", err)
+	}
+
+	job := models.Job{ID: "silenced-analysis-job", Namespace: "shop", LogLevels: []string{"ERROR"}, Microservices: []string{"log_analyzer"}}
+	defer utils.DeleteJobCursors(job.ID)
+	incidents, err := utils.RunLogScanJob(userID, job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob failed: %v", err)
+	}
+	if len(incidents) != 2 {
+		t.Fatalf("Expected an incident per line, got %+v", incidents)
+	}
+	for _, inc := range incidents {
+		silenced := inc.LogLine == "ERROR expected during deploy"
+		if silenced != (inc.Status == "Suppressed" && inc.SilenceID == "sil-deploy") {
+			t.Errorf("Expected only the deploy line to be suppressed, got %+v", inc)
+		}
+		if silenced && inc.Analysis != "" {
+			t.Errorf("Expected the suppressed line to carry no analysis, got %+v", inc)
+		}
+		if !silenced && inc.Category != "storage" {
+			t.Errorf("Expected the analyzer's category name on the incident, got %q", inc.Category)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(analysed) != 1 || analysed[0] != "ERROR disk full" {
+		t.Fatalf("Expected only the unsilenced line to be analysed, got %q", analysed)
+	}
+}
+
+func TestConcurrentSilenceEditsAreKept(t *testing.T) {
+	utils.SilencesFile = "test_silences_concurrent.json"
+	defer func() {
+		utils.ClearSilences()
+		if err := os.Remove(utils.SilencesFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove silences file: %v", err)
+		}
+	}()
+	silenceService := &services.DefaultSilenceService{}
+	userID := "concurrentsilenceuser"
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := silenceService.CreateSilence(userID, services.SilenceRequest{
+				Namespace: fmt.Sprintf("ns-%d", i), EndsAt: time.Now().Add(time.Hour),
+			}); err != nil {
+				t.Errorf("CreateSilence failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got := len(utils.GetSilences(userID)); got != 20 {
+		t.Fatalf("Expected every concurrently created silence to be kept, got %d", got)
+	}
+	// The last save wins on disk and leaves no temporary files behind
+	utils.ClearSilences()
+	if err := utils.LoadSilences(); err != nil {
+		t.Fatalf("LoadSilences failed: %v", err)
+	}
+	if got := len(utils.GetSilences(userID)); got != 20 {
+		t.Fatalf("Expected every silence in the saved file, got %d", got)
+	}
+	if leftovers, _ := filepath.Glob(utils.SilencesFile + ".*.tmp"); len(leftovers) > 0 {
+		t.Fatalf("Expected no temporary files, got %v", leftovers)
+	}
+}
+
+func TestSilenceAPIValidation(t *testing.T) {
+	utils.SilencesFile = "test_silences_api.json"
+	defer func() {
+		utils.ClearSilences()
+		if err := os.Remove(utils.SilencesFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove silences file: %v", err)
+		}
+	}()
+	silenceService := &services.DefaultSilenceService{}
+
+	post := func(payload map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		r := httptest.NewRequest("POST", "/api/silences", bytes.NewReader(body))
+		r = testhelpers.WithUser(r, "silenceapiuser")
+		w := httptest.NewRecorder()
+		handlers.HandleCreateSilence(silenceService)(w, r)
+		return w
+	}
+
+	if w := post(map[string]interface{}{"line_pattern": "([", "ends_at": time.Now().Add(time.Hour)}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for invalid regex, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post(map[string]interface{}{"namespace": "payments"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for one-off silence without ends_at, got %d %s", w.Code, w.Body.String())
+	}
+	w := post(map[string]interface{}{
+		"namespace":  "payments",
+		"comment":    "weekly deploy",
+		"recurrence": map[string]interface{}{"weekdays": []string{"Thu"}, "start_time": "21:00", "duration_minutes": 60},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create recurring silence failed: %d %s", w.Code, w.Body.String())
+	}
+	var created models.Silence
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to unmarshal silence: %v", err)
+	}
+	if created.CreatedBy != "silenceapiuser" || created.StartsAt.IsZero() {
+		t.Fatalf("Expected creator and start time to be set, got %+v", created)
+	}
+
+	r := httptest.NewRequest("DELETE", "/api/silences/"+created.ID, nil)
+	r = testhelpers.WithUser(r, "silenceapiuser")
+	rec := httptest.NewRecorder()
+	handlers.HandleDeleteSilence(silenceService)(rec, r)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Delete silence failed: %d %s", rec.Code, rec.Body.String())
+	}
+}
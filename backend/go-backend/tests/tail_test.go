@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/handlers"
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+)
+
+func TestLogTailStreamsFilteredLinesOverSSE(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	useTestCluster(t, podLogServer(map[string]map[string][]string{
+		"shop": {
+			"web-1": {"INFO request served", "ERROR upstream timeout"},
+			"web-2": {"ERROR disk full", "DEBUG cache hit"},
+		},
+	}, &queries, &mu))
+	server := httptest.NewServer(handlers.HandleK8sLogTail(&services.DefaultK8sService{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?namespace=shop&pod=web-1&pod=web-2&level=ERROR&tail_lines=5")
+	if err != nil {
+		t.Fatalf("tail request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	// The fake logs end after their lines, so the stream ends once both containers did
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	var lines []string
+	ended := 0
+	for _, msg := range strings.Split(strings.TrimSpace(string(body)), "\n\n") {
+		switch {
+		case strings.HasPrefix(msg, "event: line\n"):
+			var match models.LogMatch
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(msg, "event: line\ndata: ")), &match); err != nil {
+				t.Fatalf("invalid line event %q: %v", msg, err)
+			}
+			if match.Container != "app" || match.Level != "ERROR" {
+				t.Errorf("Expected tagged ERROR lines, got %+v", match)
+			}
+			lines = append(lines, match.Pod+": "+match.Line)
+		case strings.HasPrefix(msg, "event: stream_end\n"):
+			ended++
+		}
+	}
+	if len(lines) != 2 || !containsLine(lines, "web-1: ERROR upstream timeout") || !containsLine(lines, "web-2: ERROR disk full") {
+		t.Fatalf("Expected the ERROR line of each pod, got %q", lines)
+	}
+	if ended != 2 {
+		t.Fatalf("Expected a stream_end event per container, got %d in %q", ended, body)
+	}
+	mu.Lock()
+	for _, q := range queries {
+		if !strings.Contains(q, "follow=true") || !strings.Contains(q, "tailLines=5") {
+			t.Errorf("Expected followed log requests, got %q", q)
+		}
+	}
+	mu.Unlock()
+
+	if resp, err := http.Get(server.URL + "?namespace=shop"); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without pods or selector, got %v %v", resp, err)
+	}
+	for query, want := range map[string]string{
+		"?namespace=shop&pod=gone":                    "no pods match the request",
+		"?namespace=shop&pod=web-1&container=sidecar": `no container named "sidecar" in the 1 matching pod(s)`,
+	} {
+		resp, err := http.Get(server.URL + query)
+		if err != nil {
+			t.Fatalf("tail request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), want) {
+			t.Errorf("%s: expected 400 with %q, got %d %q", query, want, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestLogTailStopsFollowersWhenClientDisconnects(t *testing.T) {
+	followerClosed := make(chan struct{})
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/pods") {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","items":[{"metadata":{"name":"web-1","namespace":"shop"},"spec":{"containers":[{"name":"app"}]}}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s ERROR first\n", podLogTime.Format(time.RFC3339Nano))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // a log that never ends
+		close(followerClosed)
+	}))
+	server := httptest.NewServer(handlers.HandleK8sLogTail(&services.DefaultK8sService{}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?namespace=shop&pod=web-1", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("tail request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || line != "event: line\n" {
+		t.Fatalf("Expected a line event first, got %q %v", line, err)
+	}
+	cancel()
+	select {
+	case <-followerClosed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the pod log stream to be closed after the client disconnected")
+	}
+}
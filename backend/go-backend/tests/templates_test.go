@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestJobTemplateInstantiateAndApply(t *testing.T) {
+	utils.ClearJobs()
+	utils.ClearTemplates()
+	utils.JobsFile = "test_jobs_templates.json"
+	utils.TemplatesFile = "test_templates_data.json"
+	defer func() {
+		for _, f := range []string{utils.JobsFile, utils.TemplatesFile} {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				t.Errorf("failed to remove %s: %v", f, err)
+			}
+		}
+	}()
+	userID := "templateuser"
+	templateService := &services.DefaultTemplateService{}
+	jobService := &services.DefaultJobService{}
+
+	tmpl, err := templateService.CreateTemplate(userID, services.TemplateRequest{
+		Name:      "Namespace errors",
+		JobName:   "{{namespace}} errors on {{cluster}}",
+		Cluster:   "{{cluster}}",
+		Namespace: "{{namespace}}",
+		LogLevels: []string{"ERROR"},
+		Interval:  60,
+	})
+	if err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+
+	_, err = templateService.InstantiateTemplate(userID, tmpl.ID, services.InstantiateTemplateRequest{
+		Params: []map[string]string{{"namespace": "checkout"}},
+	})
+	if !errors.Is(err, services.ErrInvalidTemplateRequest) {
+		t.Fatalf("Expected missing placeholder error, got %v", err)
+	}
+
+	jobs, err := templateService.InstantiateTemplate(userID, tmpl.ID, services.InstantiateTemplateRequest{
+		Params: []map[string]string{
+			{"namespace": "checkout", "cluster": "prod"},
+			{"namespace": "search", "cluster": "prod"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InstantiateTemplate failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[1].Namespace != "search" || jobs[1].Name != "search errors on prod" || jobs[1].TemplateID != tmpl.ID {
+		t.Fatalf("Unexpected instantiated jobs: %+v", jobs)
+	}
+
+	// A template change is previewed first, then applied to every derived job
+	_, err = templateService.UpdateTemplate(userID, tmpl.ID, services.TemplateRequest{
+		Name:      "Namespace errors",
+		JobName:   "{{namespace}} errors on {{cluster}}",
+		Cluster:   "{{cluster}}",
+		Namespace: "{{namespace}}",
+		LogLevels: []string{"ERROR", "CRITICAL"},
+		Interval:  300,
+	})
+	if err != nil {
+		t.Fatalf("UpdateTemplate failed: %v", err)
+	}
+	changes, err := templateService.PreviewTemplateApply(userID, tmpl.ID)
+	if err != nil {
+		t.Fatalf("PreviewTemplateApply failed: %v", err)
+	}
+	if len(changes) != 2 || strings.Join(changes[0].Changed, ",") != "log_levels,interval" {
+		t.Fatalf("Expected log_levels and interval to change on both jobs, got %+v", changes)
+	}
+	if stored := utils.GetJobs(userID); stored[0].Interval != 60 {
+		t.Fatalf("Preview must not modify jobs, got interval %d", stored[0].Interval)
+	}
+	if _, err := templateService.ApplyTemplate(userID, tmpl.ID); err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	for _, job := range utils.GetJobs(userID) {
+		if job.Interval != 300 || len(job.LogLevels) != 2 {
+			t.Fatalf("Expected template change to be applied, got %+v", job)
+		}
+	}
+
+	clone, err := jobService.CloneLogScanJob(userID, jobs[0].ID, services.CloneJobRequest{Namespace: "payments"})
+	if err != nil {
+		t.Fatalf("CloneLogScanJob failed: %v", err)
+	}
+	if clone.ID == jobs[0].ID || clone.Namespace != "payments" || clone.TemplateParams["namespace"] != "payments" {
+		t.Fatalf("Unexpected clone: %+v", clone)
+	}
+	if len(utils.GetJobs(userID)) != 3 {
+		t.Fatalf("Expected clone to be stored")
+	}
+}
+
+func TestJobTemplateApplyKeepsJobSettingsAndConcurrentEdits(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	// Every access review is allowed; duringChecks runs while ApplyTemplate checks the jobs
+	var duringChecks func()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if duringChecks != nil {
+			duringChecks()
+			duringChecks = nil
+		}
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	utils.ClearJobs()
+	utils.ClearTemplates()
+	utils.JobsFile = "test_jobs_templates_apply.json"
+	utils.TemplatesFile = "test_templates_apply.json"
+	defer func() {
+		for _, f := range []string{utils.JobsFile, utils.TemplatesFile} {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				t.Errorf("failed to remove %s: %v", f, err)
+			}
+		}
+	}()
+	userID := "templateapplyuser"
+	templateService := &services.DefaultTemplateService{Clients: fakeClients{name: "fake", client: client}}
+
+	req := services.TemplateRequest{
+		Name:          "Workload errors",
+		Namespace:     "shop",
+		Interval:      60,
+		LabelSelector: "app={{app}}",
+		Workloads:     []models.WorkloadRef{{Kind: models.WorkloadDeployment, Name: "{{app}}"}},
+		Sources:       []string{models.JobSourceLogs},
+	}
+	tmpl, err := templateService.CreateTemplate(userID, req)
+	if err != nil {
+		t.Fatalf("CreateTemplate failed: %v", err)
+	}
+	jobs, err := templateService.InstantiateTemplate(userID, tmpl.ID, services.InstantiateTemplateRequest{
+		Params: []map[string]string{{"app": "web"}},
+	})
+	if err != nil {
+		t.Fatalf("InstantiateTemplate failed: %v", err)
+	}
+	job := jobs[0]
+	if job.LabelSelector != "app=web" || len(job.Workloads) != 1 || job.Workloads[0].Name != "web" || len(job.Sources) != 1 {
+		t.Fatalf("Expected the template's selector, workloads and sources on the job, got %+v", job)
+	}
+	// A setting the template does not carry is given to the job directly
+	err = utils.UpdateJobs(userID, func(userJobs []models.Job) ([]models.Job, error) {
+		userJobs[0].Containers = &models.ContainerFilter{Include: []string{"app"}}
+		return userJobs, nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateJobs failed: %v", err)
+	}
+
+	req.Interval = 300
+	if _, err := templateService.UpdateTemplate(userID, tmpl.ID, req); err != nil {
+		t.Fatalf("UpdateTemplate failed: %v", err)
+	}
+	duringChecks = func() {
+		if err := utils.AddJob(userID, models.Job{ID: "created-meanwhile", UserID: userID, Namespace: "shop", Interval: 60}); err != nil {
+			t.Errorf("AddJob failed: %v", err)
+		}
+	}
+	changes, err := templateService.ApplyTemplate(userID, tmpl.ID)
+	if err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Error != "" || strings.Join(changes[0].Changed, ",") != "interval" {
+		t.Fatalf("Expected only interval to change, got %+v", changes)
+	}
+	stored := utils.GetJobs(userID)
+	if len(stored) != 2 || stored[1].ID != "created-meanwhile" {
+		t.Fatalf("Expected the job created during the apply to be kept, got %+v", stored)
+	}
+	if stored[0].Interval != 300 || stored[0].Containers == nil || stored[0].LabelSelector != "app=web" {
+		t.Fatalf("Expected the job to keep its own settings, got %+v", stored[0])
+	}
+
+	// A derived job edited while its checks run is left as the edit made it
+	req.Interval = 600
+	if _, err := templateService.UpdateTemplate(userID, tmpl.ID, req); err != nil {
+		t.Fatalf("UpdateTemplate failed: %v", err)
+	}
+	duringChecks = func() {
+		err := utils.UpdateJobs(userID, func(userJobs []models.Job) ([]models.Job, error) {
+			userJobs[0].Containers = &models.ContainerFilter{Include: []string{"sidecar"}}
+			return userJobs, nil
+		})
+		if err != nil {
+			t.Errorf("UpdateJobs failed: %v", err)
+		}
+	}
+	changes, err = templateService.ApplyTemplate(userID, tmpl.ID)
+	if err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Error, "changed while the template was applied") {
+		t.Fatalf("Expected the concurrently edited job to be reported, got %+v", changes)
+	}
+	if stored := utils.GetJobs(userID); stored[0].Containers.Include[0] != "sidecar" || stored[0].Interval != 300 {
+		t.Fatalf("Expected the concurrent edit to be kept, got %+v", stored[0])
+	}
+}
+
+func TestJobUpdateKeepsJobsAddedDuringChecks(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}})
+	userID := "jobupdateuser"
+	// duringChecks runs while the update's access reviews run
+	var duringChecks func()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if duringChecks != nil {
+			duringChecks()
+			duringChecks = nil
+		}
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		return true, review, nil
+	})
+	utils.ClearJobs()
+	utils.JobsFile = "test_jobs_update_concurrent.json"
+	defer func() {
+		if err := os.Remove(utils.JobsFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove %s: %v", utils.JobsFile, err)
+		}
+	}()
+	jobService := &services.DefaultJobService{}
+	job, err := jobService.CreateLogScanJob(userID, services.CreateJobRequest{Name: "shop", Namespace: "shop", Interval: 60})
+	if err != nil {
+		t.Fatalf("CreateLogScanJob failed: %v", err)
+	}
+	duringChecks = func() {
+		if err := utils.AddJob(userID, models.Job{ID: "added-meanwhile", UserID: userID, Namespace: "shop", Interval: 60}); err != nil {
+			t.Errorf("AddJob failed: %v", err)
+		}
+	}
+	jobService.Clients = fakeClients{name: "fake", client: client}
+	jobs, err := jobService.UpdateLogScanJob(userID, job.ID, services.UpdateJobRequest{Name: "shop", Namespace: "shop", Interval: 120})
+	if err != nil {
+		t.Fatalf("UpdateLogScanJob failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Interval != 120 || jobs[1].ID != "added-meanwhile" {
+		t.Fatalf("Expected the update and the concurrently added job to be kept, got %+v", jobs)
+	}
+}
+
+func TestConcurrentTemplateEditsAreKept(t *testing.T) {
+	utils.ClearTemplates()
+	utils.TemplatesFile = "test_templates_concurrent.json"
+	defer func() {
+		utils.ClearTemplates()
+		if err := os.Remove(utils.TemplatesFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove %s: %v", utils.TemplatesFile, err)
+		}
+	}()
+	templateService := &services.DefaultTemplateService{}
+	userID := "concurrenttemplateuser"
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := templateService.CreateTemplate(userID, services.TemplateRequest{Name: "errors", Namespace: "shop", Interval: 60}); err != nil {
+				t.Errorf("CreateTemplate failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	utils.ClearTemplates()
+	if err := utils.LoadTemplates(); err != nil {
+		t.Fatalf("LoadTemplates failed: %v", err)
+	}
+	if got := len(utils.GetTemplates(userID)); got != 20 {
+		t.Fatalf("Expected every concurrently created template to be saved, got %d", got)
+	}
+}
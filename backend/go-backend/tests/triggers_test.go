@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+)
+
+func TestSchedulerFiresTriggersAndStopsLoops(t *testing.T) {
+	userID := "triggeruser"
+	store := &memJobStore{jobs: map[string][]models.Job{
+		userID: {
+			{
+				ID: "scan-a", Namespace: "payments", Interval: 3600, LastRun: time.Now().Add(-2 * time.Hour),
+				Triggers: []models.JobTrigger{{
+					Condition:   models.TriggerOnIncident,
+					TargetJobID: "scan-b",
+					MinSeverity: "High",
+					Params:      models.TriggerParams{Namespace: "payments-db", LogLevels: []string{"DEBUG"}},
+				}},
+			},
+			{
+				// Not due on its own; only runs when scan-a triggers it. Its trigger back to
+				// scan-a closes a loop that the scheduler must cut.
+				ID: "scan-b", Namespace: "databases", Interval: 3600, LastRun: time.Now(),
+				Triggers: []models.JobTrigger{{Condition: models.TriggerOnIncident, TargetJobID: "scan-a"}},
+			},
+		},
+	}}
+	incidentStore := &memIncidentStore{}
+	var mu sync.Mutex
+	var runs []models.Job
+	executor := funcExecutor(func(userID string, job models.Job) ([]models.Incident, error) {
+		mu.Lock()
+		runs = append(runs, job)
+		mu.Unlock()
+		return []models.Incident{{ID: "inc-" + job.ID, JobID: job.ID, Severity: "Critical", Status: "Open"}}, nil
+	})
+	s := utils.NewScheduler(store, incidentStore, nil, executor)
+	go s.Run()
+	defer s.Stop()
+
+	waitFor(t, 3*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(runs) >= 2
+	})
+	// Give a looping trigger the chance to show up before asserting
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 2 {
+		t.Fatalf("Expected scan-a and one triggered scan-b run, got %d runs: %+v", len(runs), runs)
+	}
+	triggered := runs[1]
+	if triggered.ID != "scan-b" || triggered.TriggeredBy != "scan-a" {
+		t.Fatalf("Expected scan-b to be triggered by scan-a, got %+v", triggered)
+	}
+	if triggered.Namespace != "payments-db" || len(triggered.LogLevels) != 1 || triggered.LogLevels[0] != "DEBUG" {
+		t.Fatalf("Expected trigger params to override the target, got %+v", triggered)
+	}
+	if last := store.GetJobs()[userID][1].LastRun; time.Since(last) > time.Minute {
+		t.Fatalf("Triggered run must not change scan-b's schedule")
+	}
+
+	incidentStore.mu.Lock()
+	defer incidentStore.mu.Unlock()
+	for _, inc := range incidentStore.incidents {
+		if inc.JobID == "scan-b" && inc.TriggeredBy != "scan-a" {
+			t.Errorf("Expected scan-b incident to record its trigger, got %+v", inc)
+		}
+	}
+}
+
+func TestJobTriggerValidation(t *testing.T) {
+	utils.ClearJobs()
+	utils.JobsFile = "test_jobs_triggers.json"
+	defer func() {
+		if err := os.Remove(utils.JobsFile); err != nil && !os.IsNotExist(err) {
+			t.Errorf("failed to remove jobs file: %v", err)
+		}
+	}()
+	userID := "triggervalidationuser"
+	jobService := &services.DefaultJobService{}
+
+	target, err := jobService.CreateLogScanJob(userID, services.CreateJobRequest{Name: "target", Namespace: "default", Interval: 60})
+	if err != nil {
+		t.Fatalf("CreateLogScanJob failed: %v", err)
+	}
+	invalid := []models.JobTrigger{
+		{Condition: "on_success", TargetJobID: target.ID},
+		{Condition: models.TriggerOnFailure, TargetJobID: "missing"},
+		{Condition: models.TriggerOnIncident, TargetJobID: target.ID, MinSeverity: "urgent"},
+	}
+	for _, trigger := range invalid {
+		_, err := jobService.CreateLogScanJob(userID, services.CreateJobRequest{
+			Namespace: "default", Interval: 60, Triggers: []models.JobTrigger{trigger},
+		})
+		if !errors.Is(err, services.ErrInvalidJobTrigger) {
+			t.Errorf("Expected ErrInvalidJobTrigger for %+v, got %v", trigger, err)
+		}
+	}
+	_, err = jobService.UpdateLogScanJob(userID, target.ID, services.UpdateJobRequest{
+		Namespace: "default", Interval: 60,
+		Triggers: []models.JobTrigger{{Condition: models.TriggerOnFailure, TargetJobID: target.ID}},
+	})
+	if !errors.Is(err, services.ErrInvalidJobTrigger) {
+		t.Fatalf("Expected self-trigger to be rejected, got %v", err)
+	}
+	if _, err := jobService.CreateLogScanJob(userID, services.CreateJobRequest{
+		Namespace: "default", Interval: 60,
+		Triggers: []models.JobTrigger{{Condition: models.TriggerOnFailure, TargetJobID: target.ID}},
+	}); err != nil {
+		t.Fatalf("Expected valid trigger to be accepted, got %v", err)
+	}
+}
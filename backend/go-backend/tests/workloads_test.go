@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/go-backend/models"
+	"backend/go-backend/services"
+	"backend/go-backend/utils"
+)
+
+func TestJobsTargetWorkloadsAndRecordThem(t *testing.T) {
+	controller := func(kind, name string) string {
+		return fmt.Sprintf(`"ownerReferences":[{"apiVersion":"v1","kind":%q,"name":%q,"uid":"u","controller":true}]`, kind, name)
+	}
+	pod := func(name, ownerKind, ownerName string) string {
+		return `{"metadata":{"name":"` + name + `","namespace":"shop",` + controller(ownerKind, ownerName) + `},"spec":{"containers":[{"name":"app"}]},` +
+			`"status":{"phase":"Running","containerStatuses":[{"name":"app","containerID":"containerd://` + name + `","state":{"running":{}}}]}}`
+	}
+	finished := func(condition string, at time.Time) string {
+		return `"status":{"conditions":[{"type":"` + condition + `","status":"True","lastTransitionTime":"` + at.UTC().Format(time.RFC3339) + `"}]}`
+	}
+	responses := map[string]string{
+		"/api/v1/namespaces/shop/pods": `{"kind":"PodList","apiVersion":"v1","items":[` + strings.Join([]string{
+			pod("web-abc-1", "ReplicaSet", "web-abc"),
+			pod("admin-xyz-1", "ReplicaSet", "admin-xyz"),
+			pod("db-0", "StatefulSet", "db"),
+			pod("nightly-3-a", "Job", "nightly-3"), // running
+			pod("nightly-2-a", "Job", "nightly-2"), // failed an hour ago
+			pod("nightly-1-a", "Job", "nightly-1"), // failed two days ago
+			pod("nightly-0-a", "Job", "nightly-0"), // succeeded
+		}, ",") + `]}`,
+		"/apis/apps/v1/namespaces/shop/replicasets": `{"kind":"ReplicaSetList","apiVersion":"apps/v1","items":[` +
+			`{"metadata":{"name":"web-abc","namespace":"shop",` + controller("Deployment", "web") + `},"spec":{"selector":{}}},` +
+			`{"metadata":{"name":"admin-xyz","namespace":"shop",` + controller("Deployment", "admin") + `},"spec":{"selector":{}}}]}`,
+		"/apis/batch/v1/namespaces/shop/jobs": `{"kind":"JobList","apiVersion":"batch/v1","items":[` +
+			`{"metadata":{"name":"nightly-3","namespace":"shop",` + controller("CronJob", "nightly") + `},"spec":{"template":{"spec":{"containers":[]}}}},` +
+			`{"metadata":{"name":"nightly-2","namespace":"shop",` + controller("CronJob", "nightly") + `},"spec":{"template":{"spec":{"containers":[]}}},` + finished("Failed", time.Now().Add(-time.Hour)) + `},` +
+			`{"metadata":{"name":"nightly-1","namespace":"shop",` + controller("CronJob", "nightly") + `},"spec":{"template":{"spec":{"containers":[]}}},` + finished("Failed", time.Now().Add(-48*time.Hour)) + `},` +
+			`{"metadata":{"name":"nightly-0","namespace":"shop",` + controller("CronJob", "nightly") + `},"spec":{"template":{"spec":{"containers":[]}}},` + finished("Complete", time.Now().Add(-time.Hour)) + `}]}`,
+	}
+	var mu sync.Mutex
+	var logsRead []string
+	useTestCluster(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/log") {
+			name := strings.Split(r.URL.Path, "/")[6]
+			mu.Lock()
+			logsRead = append(logsRead, name)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "%s ERROR failure in %s\n", podLogTime.Format(time.RFC3339Nano), name)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+
+	job := models.Job{
+		ID: "workload-job", Namespace: "shop", LogLevels: []string{"ERROR"},
+		Workloads: []models.WorkloadRef{
+			{Kind: models.WorkloadDeployment, Name: "web"},
+			{Kind: models.WorkloadStatefulSet, Name: "db"},
+			{Kind: models.WorkloadCronJob, Name: "nightly"},
+		},
+	}
+	incidents, err := utils.RunLogScanJob("workloaduser", job)
+	if err != nil {
+		t.Fatalf("RunLogScanJob failed: %v", err)
+	}
+	want := map[string]string{
+		"web-abc-1":   "Deployment/web",
+		"db-0":        "StatefulSet/db",
+		"nightly-3-a": "CronJob/nightly",
+		"nightly-2-a": "CronJob/nightly",
+	}
+	if len(incidents) != len(want) {
+		t.Fatalf("Expected an incident per targeted pod, got %+v", incidents)
+	}
+	for _, inc := range incidents {
+		if workload, ok := want[inc.Pod]; !ok || inc.Workload != workload || inc.LogLine != "ERROR failure in "+inc.Pod {
+			t.Errorf("Unexpected incident %+v", inc)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logsRead) != len(want) {
+		t.Fatalf("Expected only the targeted pods' logs to be read, got %q", logsRead)
+	}
+}
+
+func TestJobWorkloadsAreValidated(t *testing.T) {
+	svc := &services.DefaultJobService{}
+	for _, workloads := range [][]models.WorkloadRef{
+		{{Kind: "ReplicaSet", Name: "web-abc"}},
+		{{Kind: models.WorkloadDeployment}},
+		{{Kind: models.WorkloadDeployment, Name: "web"}, {Kind: models.WorkloadDeployment, Name: "web"}},
+	} {
+		_, err := svc.CreateLogScanJob("workloaduser", services.CreateJobRequest{Namespace: "shop", Interval: 60, Workloads: workloads})
+		if !errors.Is(err, services.ErrInvalidWorkload) {
+			t.Errorf("Expected ErrInvalidWorkload for %+v, got %v", workloads, err)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/models"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ErrUnknownCluster is returned when a cluster name is not in the registry
+var ErrUnknownCluster = errors.New("unknown cluster")
+
+// ErrNoClusters is returned when neither in-cluster config, a kubeconfig nor registered credentials are available
+var ErrNoClusters = errors.New("no Kubernetes clusters configured")
+
+const (
+	ClusterSourceInCluster  = "in-cluster"
+	ClusterSourceKubeconfig = "kubeconfig"
+	ClusterSourceRegistered = "registered"
+
+	// clusterCheckTimeout bounds the connectivity check of a single cluster
+	clusterCheckTimeout = 5 * time.Second
+)
+
+type clusterEntry struct {
+	name    string
+	source  string
+	config  *rest.Config
+	client  *kubernetes.Clientset
+	clientM sync.Mutex
+}
+
+var (
+	clustersMutex  sync.RWMutex
+	clusters       map[string]*clusterEntry
+	defaultCluster string
+)
+
+// LoadClusterRegistry (re)builds the cluster registry from, in order:
+//   - the in-cluster service account, named by CLUSTER_NAME (default "in-cluster")
+//   - every context of the kubeconfig at KUBECONFIG or $HOME/.kube/config
+//   - the credentials listed in the JSON file at CLUSTER_CREDENTIALS_FILE
+//
+// The default cluster, used when a request or job names none, is the in-cluster
+// entry when present and otherwise the kubeconfig's current context.
+func LoadClusterRegistry() error {
+	entries := make(map[string]*clusterEntry)
+	def := ""
+	add := func(name, source string, config *rest.Config) {
+		if _, exists := entries[name]; exists {
+			logger.Logger.Warn("[Clusters] Duplicate cluster name, keeping the first:", name)
+			return
+		}
+		entries[name] = &clusterEntry{name: name, source: source, config: config}
+	}
+
+	if config, err := rest.InClusterConfig(); err == nil {
+		name := os.Getenv("CLUSTER_NAME")
+		if name == "" {
+			name = ClusterSourceInCluster
+		}
+		add(name, ClusterSourceInCluster, config)
+		def = name
+	}
+
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		kubeconfig = os.ExpandEnv("$HOME/.kube/config")
+	}
+	if raw, err := clientcmd.LoadFromFile(kubeconfig); err == nil {
+		contexts := make([]string, 0, len(raw.Contexts))
+		for name := range raw.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+		for _, name := range contexts {
+			config, err := clientcmd.NewNonInteractiveClientConfig(*raw, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+			if err != nil {
+				logger.Logger.Warn("[Clusters] Skipping kubeconfig context ", name, ": ", err)
+				continue
+			}
+			add(name, ClusterSourceKubeconfig, config)
+		}
+		if def == "" && entries[raw.CurrentContext] != nil {
+			def = raw.CurrentContext
+		}
+	} else if !os.IsNotExist(err) {
+		logger.Logger.Warn("[Clusters] Failed to load kubeconfig ", kubeconfig, ": ", err)
+	}
+
+	if path := os.Getenv("CLUSTER_CREDENTIALS_FILE"); path != "" {
+		creds, err := loadClusterCredentials(path)
+		if err != nil {
+			return err
+		}
+		for _, c := range creds {
+			config, err := configForCredential(c)
+			if err != nil {
+				return err
+			}
+			add(c.Name, ClusterSourceRegistered, config)
+		}
+	}
+
+	if def == "" && len(entries) > 0 {
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		def = names[0]
+	}
+
+	clustersMutex.Lock()
+	clusters = entries
+	defaultCluster = def
+	clustersMutex.Unlock()
+	logger.Logger.Info("[Clusters] Loaded ", len(entries), " cluster(s), default: ", def)
+	return nil
+}
+
+func loadClusterCredentials(path string) ([]models.ClusterCredential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster credentials: %w", err)
+	}
+	var creds []models.ClusterCredential
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("decoding cluster credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func configForCredential(c models.ClusterCredential) (*rest.Config, error) {
+	if c.Name == "" || c.Server == "" {
+		return nil, fmt.Errorf("registered cluster needs a name and a server")
+	}
+	config := &rest.Config{
+		Host:        c.Server,
+		BearerToken: c.Token,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: c.InsecureSkipTLSVerify,
+		},
+	}
+	if c.CertificateAuthorityData != "" {
+		ca, err := base64.StdEncoding.DecodeString(c.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("registered cluster %s: invalid certificate_authority_data: %v", c.Name, err)
+		}
+		config.TLSClientConfig.CAData = ca
+	}
+	return config, nil
+}
+
+// lookupCluster resolves a cluster name, or the default cluster for an empty name,
+// loading the registry on first use
+func lookupCluster(name string) (*clusterEntry, error) {
+	clustersMutex.RLock()
+	loaded := clusters != nil
+	clustersMutex.RUnlock()
+	if !loaded {
+		if err := LoadClusterRegistry(); err != nil {
+			return nil, err
+		}
+	}
+	clustersMutex.RLock()
+	defer clustersMutex.RUnlock()
+	if name == "" {
+		if defaultCluster == "" {
+			return nil, ErrNoClusters
+		}
+		name = defaultCluster
+	}
+	entry, ok := clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCluster, name)
+	}
+	return entry, nil
+}
+
+// clientset returns the cluster's client, building it on first use
+func (entry *clusterEntry) clientset() (kubernetes.Interface, error) {
+	entry.clientM.Lock()
+	defer entry.clientM.Unlock()
+	if entry.client == nil {
+		client, err := kubernetes.NewForConfig(entry.config)
+		if err != nil {
+			return nil, err
+		}
+		entry.client = client
+	}
+	return entry.client, nil
+}
+
+// ClientForCluster returns a (cached) client for the named cluster, or for the
+// default cluster when name is empty
+func ClientForCluster(name string) (kubernetes.Interface, error) {
+	entry, err := lookupCluster(name)
+	if err != nil {
+		return nil, err
+	}
+	return entry.clientset()
+}
+
+// ClientFactory hands out Kubernetes clients. Client resolves a cluster argument (empty
+// for the default cluster) to the cluster's name and returns its client, which is shared
+// by every caller.
+type ClientFactory interface {
+	Client(cluster string) (string, kubernetes.Interface, error)
+}
+
+// RegistryClients is the ClientFactory of the cluster registry: one client is built per
+// cluster on first use and reused until the registry is reloaded
+type RegistryClients struct{}
+
+func (RegistryClients) Client(cluster string) (string, kubernetes.Interface, error) {
+	entry, err := lookupCluster(cluster)
+	if err != nil {
+		return "", nil, err
+	}
+	client, err := entry.clientset()
+	if err != nil {
+		return "", nil, err
+	}
+	return entry.name, client, nil
+}
+
+// ClientsOrRegistry returns clients, or RegistryClients when it is nil
+func ClientsOrRegistry(clients ClientFactory) ClientFactory {
+	if clients == nil {
+		return RegistryClients{}
+	}
+	return clients
+}
+
+// ResolveClusterName returns the registry name a cluster argument refers to, which for
+// an empty name is the default cluster
+func ResolveClusterName(name string) (string, error) {
+	entry, err := lookupCluster(name)
+	if err != nil {
+		return "", err
+	}
+	return entry.name, nil
+}
+
+// ListClusters returns every registered cluster with its current connectivity status.
+// Clusters are checked concurrently, each bounded by a short timeout.
+func ListClusters() ([]models.ClusterInfo, error) {
+	if _, err := lookupCluster(""); err != nil && !errors.Is(err, ErrNoClusters) {
+		return nil, err
+	}
+	clustersMutex.RLock()
+	entries := make([]*clusterEntry, 0, len(clusters))
+	for _, entry := range clusters {
+		entries = append(entries, entry)
+	}
+	def := defaultCluster
+	clustersMutex.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	infos := make([]models.ClusterInfo, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		infos[i] = models.ClusterInfo{
+			Name:    entry.name,
+			Cluster: entry.name,
+			Source:  entry.source,
+			Server:  entry.config.Host,
+			Default: entry.name == def,
+		}
+		wg.Add(1)
+		go func(info *models.ClusterInfo, config *rest.Config) {
+			defer wg.Done()
+			checkConfig := rest.CopyConfig(config)
+			checkConfig.Timeout = clusterCheckTimeout
+			client, err := kubernetes.NewForConfig(checkConfig)
+			if err == nil {
+				version, verr := client.Discovery().ServerVersion()
+				if verr == nil {
+					info.Reachable = true
+					info.Version = version.GitVersion
+					return
+				}
+				err = verr
+			}
+			info.Error = err.Error()
+		}(&infos[i], entry.config)
+	}
+	wg.Wait()
+	return infos, nil
+}
+
+// ResetClusterRegistryForTest drops the registry so the next lookup reloads it
+func ResetClusterRegistryForTest() {
+	clustersMutex.Lock()
+	clusters = nil
+	defaultCluster = ""
+	clustersMutex.Unlock()
+}
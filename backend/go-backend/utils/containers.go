@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+
+	"backend/go-backend/models"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ContainerNames returns the containers of the pod a filter selects, in the order
+// regular, init, ephemeral. A nil filter selects the regular containers.
+func ContainerNames(pod *corev1.Pod, filter *models.ContainerFilter) []string {
+	var f models.ContainerFilter
+	if filter != nil {
+		f = *filter
+	}
+	var names []string
+	add := func(name string) {
+		if containerSelected(f, name) {
+			names = append(names, name)
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		add(c.Name)
+	}
+	if f.Init {
+		for _, c := range pod.Spec.InitContainers {
+			add(c.Name)
+		}
+	}
+	if f.Ephemeral {
+		for _, c := range pod.Spec.EphemeralContainers {
+			add(c.Name)
+		}
+	}
+	return names
+}
+
+func containerSelected(f models.ContainerFilter, name string) bool {
+	included := len(f.Include) == 0
+	for _, pattern := range f.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateContainerFilter checks that every include and exclude pattern is a valid glob
+func ValidateContainerFilter(filter *models.ContainerFilter) error {
+	if filter == nil {
+		return nil
+	}
+	for _, patterns := range [][]string{filter.Include, filter.Exclude} {
+		for _, pattern := range patterns {
+			if pattern == "" {
+				return fmt.Errorf("empty container pattern")
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid container pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// containerStatusFor returns the status of a regular, init or ephemeral container, or
+// nil when the container has none yet
+func containerStatusFor(pod *corev1.Pod, container string) *corev1.ContainerStatus {
+	for _, statuses := range [][]corev1.ContainerStatus{
+		pod.Status.ContainerStatuses,
+		pod.Status.InitContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for i := range statuses {
+			if statuses[i].Name == container {
+				return &statuses[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// warningEventSelector restricts event listings and watches to Warning events
+const warningEventSelector = "type=" + corev1.EventTypeWarning
+
+// eventWatchRetry is how long a failed event watch waits before reconnecting
+const eventWatchRetry = 10 * time.Second
+
+// KubeEvent is a Warning event read from either the core/v1 or the events.k8s.io/v1 API
+type KubeEvent struct {
+	UID       string
+	Namespace string
+	Reason    string
+	Message   string
+	Kind      string // kind and name of the object the event is about
+	Name      string
+	Node      string
+	Count     int32
+	LastSeen  time.Time
+}
+
+// EventRule maps a Warning event reason to the severity and category of its incident
+type EventRule struct {
+	Severity string
+	Category string
+}
+
+// EventReasonRules covers the Warning reasons reported by the kubelet, scheduler and
+// controllers; other Warning reasons use DefaultEventRule
+var EventReasonRules = map[string]EventRule{
+	"FailedScheduling":       {"High", "Scheduling"},
+	"BackOff":                {"High", "CrashLoop"},
+	"ErrImagePull":           {"High", "Image"},
+	"ImagePullBackOff":       {"High", "Image"},
+	"ErrImageNeverPull":      {"High", "Image"},
+	"InspectFailed":          {"High", "Image"},
+	"Failed":                 {"High", "Container"},
+	"FailedKillPod":          {"Medium", "Container"},
+	"FailedMount":            {"High", "Storage"},
+	"FailedAttachVolume":     {"High", "Storage"},
+	"FailedMapVolume":        {"High", "Storage"},
+	"Unhealthy":              {"Medium", "Probe"},
+	"ProbeWarning":           {"Low", "Probe"},
+	"OOMKilling":             {"Critical", "Resources"},
+	"Evicted":                {"High", "Resources"},
+	"FailedCreatePodSandBox": {"High", "Network"},
+	"FailedCreate":           {"High", "Workload"},
+	"NodeNotReady":           {"Critical", "Node"},
+}
+
+// DefaultEventRule applies to Warning reasons missing from EventReasonRules
+var DefaultEventRule = EventRule{Severity: "Medium", Category: "Kubernetes"}
+
+// EventRuleFor returns the rule for an event's reason. The kubelet reports image pull
+// back-offs with the same BackOff reason as crash loops, so those are told apart by message.
+func EventRuleFor(ev KubeEvent) EventRule {
+	if ev.Reason == "BackOff" && strings.Contains(ev.Message, "pulling image") {
+		return EventReasonRules["ImagePullBackOff"]
+	}
+	if rule, ok := EventReasonRules[ev.Reason]; ok {
+		return rule
+	}
+	return DefaultEventRule
+}
+
+// EventFingerprint identifies a problem across repeats of its event: the same reason
+// reported for the same object
+func EventFingerprint(cluster string, ev KubeEvent) string {
+	return strings.Join([]string{"event", cluster, ev.Namespace, ev.Kind, ev.Name, ev.Reason}, "/")
+}
+
+func eventFromCore(e *corev1.Event) KubeEvent {
+	ev := KubeEvent{
+		UID:       string(e.UID),
+		Namespace: e.Namespace,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Kind:      e.InvolvedObject.Kind,
+		Name:      e.InvolvedObject.Name,
+		Node:      e.Source.Host,
+		Count:     e.Count,
+	}
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		ev.LastSeen = e.Series.LastObservedTime.Time
+		ev.Count = e.Series.Count
+	case !e.LastTimestamp.IsZero():
+		ev.LastSeen = e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		ev.LastSeen = e.EventTime.Time
+	default:
+		ev.LastSeen = e.CreationTimestamp.Time
+	}
+	return ev
+}
+
+func eventFromEventsV1(e *eventsv1.Event) KubeEvent {
+	ev := KubeEvent{
+		UID:       string(e.UID),
+		Namespace: e.Namespace,
+		Reason:    e.Reason,
+		Message:   e.Note,
+		Kind:      e.Regarding.Kind,
+		Name:      e.Regarding.Name,
+		Node:      e.DeprecatedSource.Host,
+		Count:     e.DeprecatedCount,
+	}
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		ev.LastSeen = e.Series.LastObservedTime.Time
+		ev.Count = e.Series.Count
+	case !e.DeprecatedLastTimestamp.IsZero():
+		ev.LastSeen = e.DeprecatedLastTimestamp.Time
+	case !e.EventTime.IsZero():
+		ev.LastSeen = e.EventTime.Time
+	default:
+		ev.LastSeen = e.CreationTimestamp.Time
+	}
+	return ev
+}
+
+// ListWarningEvents returns the Warning events of a namespace from both event APIs.
+// Both APIs serve the same stored objects, so events are merged by UID; a cluster
+// that serves only one of them is still covered.
+func ListWarningEvents(clientset kubernetes.Interface, namespace string) ([]KubeEvent, error) {
+	opts := metav1.ListOptions{FieldSelector: warningEventSelector}
+	seen := make(map[string]bool)
+	var events []KubeEvent
+	add := func(ev KubeEvent) {
+		if ev.UID != "" && seen[ev.UID] {
+			return
+		}
+		seen[ev.UID] = true
+		events = append(events, ev)
+	}
+	v1List, v1Err := clientset.EventsV1().Events(namespace).List(context.Background(), opts)
+	if v1Err == nil {
+		for i := range v1List.Items {
+			add(eventFromEventsV1(&v1List.Items[i]))
+		}
+	}
+	coreList, coreErr := clientset.CoreV1().Events(namespace).List(context.Background(), opts)
+	if coreErr == nil {
+		for i := range coreList.Items {
+			add(eventFromCore(&coreList.Items[i]))
+		}
+	}
+	if v1Err != nil && coreErr != nil {
+		return nil, coreErr
+	}
+	return events, nil
+}
+
+// WatchWarningEvents streams the Warning events of a namespace to fn until ctx is done
+// or the watch fails. It watches events.k8s.io/v1, falling back to core/v1 on clusters
+// without it, and resumes from the last seen resource version when the server closes
+// the watch.
+func WatchWarningEvents(ctx context.Context, clientset kubernetes.Interface, namespace string, fn func(KubeEvent)) error {
+	useCore := false
+	resourceVersion := ""
+	for ctx.Err() == nil {
+		opts := metav1.ListOptions{FieldSelector: warningEventSelector, ResourceVersion: resourceVersion}
+		var w watch.Interface
+		var err error
+		if useCore {
+			w, err = clientset.CoreV1().Events(namespace).Watch(ctx, opts)
+		} else {
+			w, err = clientset.EventsV1().Events(namespace).Watch(ctx, opts)
+			if apierrors.IsNotFound(err) {
+				useCore = true
+				continue
+			}
+		}
+		if err != nil {
+			return err
+		}
+		for change := range w.ResultChan() {
+			switch change.Type {
+			case watch.Added, watch.Modified:
+				switch obj := change.Object.(type) {
+				case *eventsv1.Event:
+					resourceVersion = obj.ResourceVersion
+					fn(eventFromEventsV1(obj))
+				case *corev1.Event:
+					resourceVersion = obj.ResourceVersion
+					fn(eventFromCore(obj))
+				}
+			case watch.Error:
+				// Usually 410 Gone: the resource version expired, so start over
+				resourceVersion = ""
+			}
+		}
+		w.Stop()
+	}
+	return ctx.Err()
+}
+
+// eventIncidents turns the job's new Warning events into incidents, skipping repeats
+// of problems that already have one
+func eventIncidents(userID string, job models.Job, events []KubeEvent) []models.Incident {
+	findings := make([]finding, 0, len(events))
+	for _, ev := range events {
+		rule := EventRuleFor(ev)
+		f := finding{
+			Source:      models.JobSourceEvents,
+			Fingerprint: EventFingerprint(job.Cluster, ev),
+			Line:        fmt.Sprintf("%s %s/%s: %s", ev.Reason, ev.Kind, ev.Name, ev.Message),
+			Reason:      ev.Reason,
+			Object:      ev.Kind + "/" + ev.Name,
+			Namespace:   ev.Namespace,
+			Node:        ev.Node,
+			Level:       "WARN",
+			Severity:    rule.Severity,
+			Category:    rule.Category,
+			Seen:        ev.LastSeen,
+		}
+		if ev.Kind == "Pod" {
+			f.Pod = ev.Name
+		}
+		findings = append(findings, f)
+	}
+	return findingIncidents(userID, job, findings)
+}
+
+// JobHasSource reports whether a job scans the given source; jobs without sources scan logs
+func JobHasSource(job models.Job, source string) bool {
+	if len(job.Sources) == 0 {
+		return source == models.JobSourceLogs
+	}
+	return containsString(job.Sources, source)
+}
+
+// watchJobEvents stores incidents for the job's Warning events as they arrive until ctx
+// is cancelled, reconnecting after failures
+func (s *Scheduler) watchJobEvents(ctx context.Context, userID string, job models.Job) {
+	for ctx.Err() == nil {
+		err := func() error {
+			cluster, clientset, err := s.clients.Client(job.Cluster)
+			if err != nil {
+				return err
+			}
+			job.Cluster = cluster
+			return WatchWarningEvents(ctx, clientset, job.Namespace, func(ev KubeEvent) {
+				incidents := eventIncidents(userID, job, []KubeEvent{ev})
+				if len(incidents) == 0 {
+					return
+				}
+				stored := s.storeIncidents(userID, job, incidents)
+				s.fireTriggers(userID, job, stored, nil)
+			})
+		}()
+		if ctx.Err() != nil {
+			return
+		}
+		Logger.WithFields(map[string]interface{}{
+			"job":  job.ID,
+			"user": userID,
+		}).Warn("[Scheduler] Event watch failed, retrying: ", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(eventWatchRetry):
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"backend/go-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// finding is a problem detected outside the container logs, by the event or health
+// source, that becomes an incident unless a recent one shares its fingerprint
+type finding struct {
+	Source      string
+	Fingerprint string
+	Line        string
+	Reason      string
+	Object      string // e.g. "Pod/web-1"
+	Workload    string // e.g. "Deployment/web"
+	Namespace   string
+	Pod         string
+	Container   string
+	Node        string
+	Level       string
+	Severity    string
+	Category    string
+	Restart     *models.ContainerRestart
+	Seen        time.Time
+}
+
+// FindingDedupWindow is how long a problem must stay quiet before it opens a new incident;
+// repeats within the window are folded into the first one
+var FindingDedupWindow = time.Hour
+
+var (
+	findingSeenMu sync.Mutex
+	findingSeen   = make(map[string]map[string]time.Time) // jobID -> fingerprint -> last occurrence
+)
+
+// claimFinding records an occurrence of a job's finding and reports whether it should
+// open an incident: the first occurrence does, as does one after FindingDedupWindow of
+// quiet. Occurrences at or before the last one recorded (the same event listed again)
+// never do.
+func claimFinding(jobID, fingerprint string, seen time.Time) bool {
+	findingSeenMu.Lock()
+	defer findingSeenMu.Unlock()
+	if findingSeen[jobID] == nil {
+		findingSeen[jobID] = make(map[string]time.Time)
+	}
+	prev, ok := findingSeen[jobID][fingerprint]
+	if ok && !seen.After(prev) {
+		return false
+	}
+	findingSeen[jobID][fingerprint] = seen
+	return !ok || seen.Sub(prev) > FindingDedupWindow
+}
+
+// SeedFindingsFromIncidents records the fingerprints of the stored incidents as seen, so
+// problems still listed after a restart or a change of leader are not opened again
+func SeedFindingsFromIncidents() {
+	incidentsMutex.RLock()
+	defer incidentsMutex.RUnlock()
+	findingSeenMu.Lock()
+	defer findingSeenMu.Unlock()
+	for _, userIncidents := range incidents {
+		for _, inc := range userIncidents {
+			if inc.Fingerprint == "" || inc.JobID == "" {
+				continue
+			}
+			if findingSeen[inc.JobID] == nil {
+				findingSeen[inc.JobID] = make(map[string]time.Time)
+			}
+			if inc.Timestamp.After(findingSeen[inc.JobID][inc.Fingerprint]) {
+				findingSeen[inc.JobID][inc.Fingerprint] = inc.Timestamp
+			}
+		}
+	}
+}
+
+// ForgetJobFindings drops the event and health deduplication state of a deleted job
+func ForgetJobFindings(jobID string) {
+	findingSeenMu.Lock()
+	defer findingSeenMu.Unlock()
+	delete(findingSeen, jobID)
+}
+
+// findingIncidents turns the job's findings into incidents, skipping repeats of
+// problems that already have one. Silenced findings are recorded as suppressed
+// without being analysed.
+func findingIncidents(userID string, job models.Job, findings []finding) []models.Incident {
+	ms := make(map[string]bool)
+	for _, m := range job.Microservices {
+		ms[m] = true
+	}
+	var incidents []models.Incident
+	for _, f := range findings {
+		if !claimFinding(job.ID, f.Fingerprint, f.Seen) {
+			findingsObserved.WithLabelValues(f.Source, "duplicate").Inc()
+			continue
+		}
+		findingsObserved.WithLabelValues(f.Source, "new").Inc()
+		inc := models.Incident{
+			ID:             uuid.New().String(),
+			UserID:         userID,
+			JobID:          job.ID,
+			Timestamp:      f.Seen,
+			DetectedAt:     time.Now(),
+			LogLine:        f.Line,
+			Cluster:        job.Cluster,
+			Namespace:      f.Namespace,
+			Pod:            f.Pod,
+			Container:      f.Container,
+			Node:           f.Node,
+			Level:          f.Level,
+			MatchRule:      f.Source + ":" + f.Reason,
+			Restart:        f.Restart,
+			Source:         f.Source,
+			Reason:         f.Reason,
+			Object:         f.Object,
+			Workload:       f.Workload,
+			Fingerprint:    f.Fingerprint,
+			TriggeredBy:    job.TriggeredBy,
+			Title:          job.Name,
+			Service:        job.Namespace,
+			Severity:       f.Severity,
+			Status:         "Open",
+			Category:       f.Category,
+			ResolutionTime: 0.0,
+		}
+		if silence, ok := FindActiveSilence(userID, inc, inc.DetectedAt); ok {
+			inc.Status = "Suppressed"
+			inc.SilenceID = silence.ID
+			incidents = append(incidents, inc)
+			continue
+		}
+		analyzeResult, predictResult, kbResult, recResult := callMicroservicesForLog(f.Line, ms)
+		inc.Analysis = toString(analyzeResult)
+		inc.RootCause = toString(predictResult)
+		inc.Knowledge = toString(kbResult)
+		inc.Action = toString(recResult)
+		incidents = append(incidents, inc)
+	}
+	return incidents
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Health thresholds used when a job leaves them unset
+const (
+	DefaultRestartThreshold   = 5
+	DefaultPendingMinutes     = 10
+	DefaultNotReadyMinutes    = 5
+	DefaultUnavailableMinutes = 10
+)
+
+// Reasons recorded on health incidents
+const (
+	HealthCrashLoop             = "CrashLoopBackOff"
+	HealthOOMKilled             = "OOMKilled"
+	HealthHighRestarts          = "HighRestartCount"
+	HealthPodPending            = "PodPending"
+	HealthPodNotReady           = "PodNotReady"
+	HealthDeploymentUnavailable = "DeploymentUnavailable"
+)
+
+// healthThresholds returns the job's health thresholds with defaults filled in
+func healthThresholds(job models.Job) models.HealthThresholds {
+	var t models.HealthThresholds
+	if job.Health != nil {
+		t = *job.Health
+	}
+	if t.RestartCount == 0 {
+		t.RestartCount = DefaultRestartThreshold
+	}
+	if t.PendingMinutes == 0 {
+		t.PendingMinutes = DefaultPendingMinutes
+	}
+	if t.NotReadyMinutes == 0 {
+		t.NotReadyMinutes = DefaultNotReadyMinutes
+	}
+	if t.UnavailableMinutes == 0 {
+		t.UnavailableMinutes = DefaultUnavailableMinutes
+	}
+	return t
+}
+
+// checkJobHealth evaluates the status of the job's pods and of the Deployments running
+// them. Pods are reported for crash loops, OOM kills, high restart counts and being
+// Pending or not Ready for too long; Deployments for replicas that stay unavailable.
+// Every finding names the workload that owns the pod.
+func checkJobHealth(clientset kubernetes.Interface, job models.Job, now time.Time) ([]finding, error) {
+	thresholds := healthThresholds(job)
+	workloads := NewWorkloadResolver(clientset, job.Namespace)
+	pods, err := listJobPods(clientset, job, workloads)
+	if err != nil {
+		return nil, err
+	}
+	var findings []finding
+	owned := make(map[string]bool) // workloads owning one of the job's pods
+	for i := range pods {
+		pod := &pods[i]
+		workload := workloads.WorkloadFor(pod)
+		owned[workload] = true
+		podFinding := func(reason, container, severity, category, line string, seen time.Time) finding {
+			return finding{
+				Source:      models.JobSourceHealth,
+				Fingerprint: strings.Join([]string{models.JobSourceHealth, job.Cluster, pod.Namespace, "Pod", pod.Name, container, reason}, "/"),
+				Line:        line,
+				Reason:      reason,
+				Object:      "Pod/" + pod.Name,
+				Workload:    workload,
+				Namespace:   pod.Namespace,
+				Pod:         pod.Name,
+				Container:   container,
+				Node:        pod.Spec.NodeName,
+				Severity:    severity,
+				Category:    category,
+				Seen:        seen,
+			}
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodPending:
+			if age := now.Sub(pod.CreationTimestamp.Time); age > time.Duration(thresholds.PendingMinutes)*time.Minute {
+				line := fmt.Sprintf("Pod %s has been Pending for %s", pod.Name, age.Round(time.Minute))
+				if cond := podCondition(pod, corev1.PodScheduled); cond != nil && cond.Status == corev1.ConditionFalse && cond.Message != "" {
+					line += ": " + cond.Message
+				}
+				findings = append(findings, podFinding(HealthPodPending, "", "High", "Scheduling", line, now))
+			}
+		case corev1.PodRunning:
+			cond := podCondition(pod, corev1.PodReady)
+			if cond != nil && cond.Status == corev1.ConditionFalse {
+				if d := now.Sub(cond.LastTransitionTime.Time); d > time.Duration(thresholds.NotReadyMinutes)*time.Minute {
+					line := fmt.Sprintf("Pod %s has not been Ready for %s", pod.Name, d.Round(time.Minute))
+					findings = append(findings, podFinding(HealthPodNotReady, "", "Medium", "Availability", line, now))
+				}
+			}
+		}
+
+		for _, cs := range pod.Status.ContainerStatuses {
+			restart, _ := ContainerRestartFor(pod, cs.Name)
+			var containerFindings []finding
+			if waiting := cs.State.Waiting; waiting != nil && waiting.Reason == HealthCrashLoop {
+				line := fmt.Sprintf("Container %s of pod %s is in CrashLoopBackOff after %d restarts", cs.Name, pod.Name, cs.RestartCount)
+				containerFindings = append(containerFindings, podFinding(HealthCrashLoop, cs.Name, "Critical", "CrashLoop", line, now))
+			}
+			if terminated := oomTermination(cs); terminated != nil {
+				line := fmt.Sprintf("Container %s of pod %s was OOMKilled (exit code %d)", cs.Name, pod.Name, terminated.ExitCode)
+				seen := terminated.FinishedAt.Time
+				if seen.IsZero() {
+					seen = now
+				}
+				containerFindings = append(containerFindings, podFinding(HealthOOMKilled, cs.Name, "Critical", "Resources", line, seen))
+			}
+			// A crash loop or OOM kill already explains the restarts
+			if len(containerFindings) == 0 && cs.RestartCount >= thresholds.RestartCount {
+				line := fmt.Sprintf("Container %s of pod %s has restarted %d times", cs.Name, pod.Name, cs.RestartCount)
+				containerFindings = append(containerFindings, podFinding(HealthHighRestarts, cs.Name, "High", "Restarts", line, now))
+			}
+			for _, f := range containerFindings {
+				f.Restart = restart
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	deployments, err := clientset.AppsV1().Deployments(job.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := labels.Parse(job.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool) // workloads the job targets
+	for _, w := range job.Workloads {
+		listed[w.String()] = true
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		workload := "Deployment/" + d.Name
+		// Deployments without any pods left are still checked when the job targets them,
+		// or, for a job without workload targets, when its selector covers their pod
+		// template; a job pinned to pods only checks their owners
+		if !owned[workload] {
+			switch {
+			case len(job.Pods) > 0:
+				continue
+			case len(job.Workloads) > 0:
+				if !listed[workload] {
+					continue
+				}
+			case !selector.Matches(labels.Set(d.Spec.Template.Labels)):
+				continue
+			}
+		}
+		if f, ok := deploymentFinding(job, d, thresholds, now); ok {
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// deploymentFinding reports a Deployment whose unavailable replicas either broke its
+// minimum availability for longer than the threshold or stalled its rollout
+func deploymentFinding(job models.Job, d *appsv1.Deployment, thresholds models.HealthThresholds, now time.Time) (finding, bool) {
+	if d.Status.UnavailableReplicas == 0 {
+		return finding{}, false
+	}
+	unhealthy := false
+	for _, cond := range d.Status.Conditions {
+		switch {
+		case cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded":
+			unhealthy = true
+		case cond.Type == appsv1.DeploymentAvailable && cond.Status == corev1.ConditionFalse &&
+			now.Sub(cond.LastTransitionTime.Time) > time.Duration(thresholds.UnavailableMinutes)*time.Minute:
+			unhealthy = true
+		}
+	}
+	if !unhealthy {
+		return finding{}, false
+	}
+	severity := "High"
+	if d.Status.AvailableReplicas == 0 {
+		severity = "Critical"
+	}
+	workload := "Deployment/" + d.Name
+	return finding{
+		Source:      models.JobSourceHealth,
+		Fingerprint: strings.Join([]string{models.JobSourceHealth, job.Cluster, d.Namespace, workload, HealthDeploymentUnavailable}, "/"),
+		Line: fmt.Sprintf("Deployment %s has %d of %d replicas unavailable",
+			d.Name, d.Status.UnavailableReplicas, d.Status.Replicas),
+		Reason:    HealthDeploymentUnavailable,
+		Object:    workload,
+		Workload:  workload,
+		Namespace: d.Namespace,
+		Severity:  severity,
+		Category:  "Availability",
+		Seen:      now,
+	}, true
+}
+
+func podCondition(pod *corev1.Pod, condType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// oomTermination returns the container's current or last termination when the kernel
+// OOM killer ended it
+func oomTermination(cs corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if t := cs.State.Terminated; t != nil && t.Reason == HealthOOMKilled {
+		return t
+	}
+	if t := cs.LastTerminationState.Terminated; t != nil && t.Reason == HealthOOMKilled {
+		return t
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	DefaultLeaseName     = "go-backend-scheduler"
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// LeaderElectionConfig configures Lease-based leader election for the scheduler
+type LeaderElectionConfig struct {
+	LeaseName      string
+	LeaseNamespace string
+	Identity       string
+	LeaseDuration  time.Duration
+	RenewDeadline  time.Duration
+	RetryPeriod    time.Duration
+}
+
+// LeaderElectionEnabled reports whether LEADER_ELECTION_ENABLED is set
+func LeaderElectionEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("LEADER_ELECTION_ENABLED"))
+	return enabled
+}
+
+// LeaderElectionConfigFromEnv builds a LeaderElectionConfig from environment variables,
+// falling back to defaults suitable for a Deployment with the downward API.
+func LeaderElectionConfigFromEnv() LeaderElectionConfig {
+	cfg := LeaderElectionConfig{
+		LeaseName:      os.Getenv("LEADER_ELECTION_LEASE_NAME"),
+		LeaseNamespace: os.Getenv("POD_NAMESPACE"),
+		Identity:       os.Getenv("POD_NAME"),
+		LeaseDuration:  envDuration("LEADER_ELECTION_LEASE_DURATION", DefaultLeaseDuration),
+		RenewDeadline:  envDuration("LEADER_ELECTION_RENEW_DEADLINE", DefaultRenewDeadline),
+		RetryPeriod:    envDuration("LEADER_ELECTION_RETRY_PERIOD", DefaultRetryPeriod),
+	}
+	if cfg.LeaseName == "" {
+		cfg.LeaseName = DefaultLeaseName
+	}
+	if cfg.LeaseNamespace == "" {
+		cfg.LeaseNamespace = "default"
+	}
+	if cfg.Identity == "" {
+		hostname, _ := os.Hostname()
+		cfg.Identity = fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+	}
+	return cfg
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		Logger.Warnf("[LeaderElection] Invalid duration %q for %s, using %s", v, key, def)
+		return def
+	}
+	return d
+}
+
+// LeaderElector runs a callback only while this replica holds the Lease.
+// When leadership is lost it rejoins the election as a candidate.
+type LeaderElector struct {
+	client   kubernetes.Interface
+	config   LeaderElectionConfig
+	onLead   func(ctx context.Context)
+	mu       sync.RWMutex
+	isLeader bool
+	leader   string
+}
+
+// NewLeaderElector creates a LeaderElector; onLead must return once its context is cancelled
+func NewLeaderElector(client kubernetes.Interface, cfg LeaderElectionConfig, onLead func(ctx context.Context)) *LeaderElector {
+	return &LeaderElector{
+		client: client,
+		config: cfg,
+		onLead: onLead,
+	}
+}
+
+// Run participates in the election until ctx is cancelled
+func (le *LeaderElector) Run(ctx context.Context) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      le.config.LeaseName,
+			Namespace: le.config.LeaseNamespace,
+		},
+		Client: le.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: le.config.Identity,
+		},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   le.config.LeaseDuration,
+		RenewDeadline:   le.config.RenewDeadline,
+		RetryPeriod:     le.config.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            le.config.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				Logger.WithField("identity", le.config.Identity).Info("[LeaderElection] Started leading")
+				le.setLeader(true)
+				le.onLead(ctx)
+			},
+			OnStoppedLeading: func() {
+				Logger.WithField("identity", le.config.Identity).Info("[LeaderElection] Stopped leading")
+				le.setLeader(false)
+			},
+			OnNewLeader: func(identity string) {
+				Logger.WithField("leader", identity).Info("[LeaderElection] New leader observed")
+				le.mu.Lock()
+				le.leader = identity
+				le.mu.Unlock()
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	// elector.Run returns as soon as leadership is lost; keep campaigning until shutdown
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+	return nil
+}
+
+func (le *LeaderElector) setLeader(leading bool) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	le.isLeader = leading
+	if leading {
+		le.leader = le.config.Identity
+	}
+}
+
+// IsLeader reports whether this replica currently holds the Lease
+func (le *LeaderElector) IsLeader() bool {
+	le.mu.RLock()
+	defer le.mu.RUnlock()
+	return le.isLeader
+}
+
+// Leader returns the identity of the last observed leader
+func (le *LeaderElector) Leader() string {
+	le.mu.RLock()
+	defer le.mu.RUnlock()
+	return le.leader
+}
+
+// Identity returns this replica's election identity
+func (le *LeaderElector) Identity() string {
+	return le.config.Identity
+}
+
+var (
+	leaderElectorInstance *LeaderElector
+	leaderElectionCancel  context.CancelFunc
+)
+
+// StartLeaderElectedScheduler runs the scheduler only while this replica is the elected leader
+// (call once on startup instead of StartScheduler). The lease is held in the default
+// cluster of clients, which jobs also read their clusters through; nil uses the registry.
+func StartLeaderElectedScheduler(cfg LeaderElectionConfig, clients ClientFactory) error {
+	_, clientset, err := ClientsOrRegistry(clients).Client("")
+	if err != nil {
+		return err
+	}
+	schedulerOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		leaderElectionCancel = cancel
+		leaderElectorInstance = NewLeaderElector(clientset, cfg, func(ctx context.Context) {
+			RunSchedulerUntil(ctx, clients)
+		})
+		go func() {
+			if err := leaderElectorInstance.Run(ctx); err != nil {
+				Logger.Error("[LeaderElection] Leader election failed:", err)
+			}
+		}()
+	})
+	return nil
+}
+
+// RunSchedulerUntil runs a fresh scheduler, published as the ActiveScheduler, until ctx
+// is cancelled (leadership lost or shutdown). Jobs still running then finish without
+// writing anything, so they cannot race the next leader. Jobs read their clusters through
+// clients; nil uses the cluster registry.
+func RunSchedulerUntil(ctx context.Context, clients ClientFactory) {
+	SeedFindingsFromIncidents()
+	s := NewScheduler(DefaultJobStore{}, DefaultIncidentStore{}, nil, DefaultJobExecutor{Clients: clients})
+	setActiveScheduler(s)
+	defer clearActiveScheduler(s)
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	s.Run()
+}
+
+// LeadershipStatus reports whether leader election is active, whether this replica leads,
+// and the identity of the current leader. Without leader election every replica leads.
+func LeadershipStatus() (enabled bool, leading bool, leader string) {
+	if leaderElectorInstance == nil {
+		return false, true, ""
+	}
+	return true, leaderElectorInstance.IsLeader(), leaderElectorInstance.Leader()
+}
@@ -0,0 +1,342 @@
+package utils
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats recognised by ParseLogLine
+const (
+	LogFormatJSON   = "json"
+	LogFormatLogfmt = "logfmt"
+	LogFormatKlog   = "klog"
+	LogFormatGo     = "go"
+	LogFormatPython = "python"
+	LogFormatJava   = "java"
+	LogFormatText   = "text"
+)
+
+// ParsedLog is a log line split into the fields scans filter on. Level is normalised
+// to one of CRITICAL, ERROR, WARN, INFO, DEBUG or TRACE, or "" when the line has none.
+type ParsedLog struct {
+	Format    string
+	Level     string
+	Message   string
+	Timestamp time.Time // zero when the line carries no timestamp of its own
+	Logger    string
+	Error     string
+}
+
+// SearchFields returns the parsed fields search patterns are applied to
+func (p ParsedLog) SearchFields() []string {
+	fields := []string{p.Message}
+	if p.Error != "" {
+		fields = append(fields, p.Error)
+	}
+	if p.Logger != "" {
+		fields = append(fields, p.Logger)
+	}
+	return fields
+}
+
+var (
+	// I0604 10:30:00.123456    1 controller.go:42] message
+	klogPattern = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}:\d{2}:\d{2}\.\d+)\s+\d+ ([^\]]+)\] ?(.*)$`)
+	// 2024-06-04T10:30:00.000Z	ERROR	payments/charge.go:88	message	{"k":"v"} (zap console)
+	zapPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+)\t(DEBUG|INFO|WARN|ERROR|DPANIC|PANIC|FATAL)\t(?:(\S+)\t)?(.*)$`)
+	// 2024/06/04 10:30:00 message (standard library log)
+	goStdPattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?) (.*)$`)
+	// 2024-06-04 10:30:00,123 - app.db - ERROR - message
+	pythonPattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2},\d{3}) - (\S+) - (CRITICAL|ERROR|WARNING|INFO|DEBUG) - (.*)$`)
+	// ERROR:app.db:message (logging.basicConfig default) or ERROR:     message (uvicorn)
+	pythonBasicPattern = regexp.MustCompile(`^(CRITICAL|ERROR|WARNING|INFO|DEBUG):(?:([\w.]+):|\s+)(.*)$`)
+	// 2024-06-04 10:30:00.123 [main] ERROR com.example.App - message (logback/log4j),
+	// 2024-06-04T10:30:00.123Z ERROR 1 --- [main] c.e.App : message (Spring Boot)
+	javaPattern = regexp.MustCompile(`^((?:\d{4}-\d{2}-\d{2}[ T])?\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\s+(?:\[[^\]]+\]\s+)?(TRACE|DEBUG|INFO|WARN|ERROR|FATAL|SEVERE)\s+(?:\d+\s+---\s+)?(?:\[[^\]]+\]\s+)?([\w.$]+)\s*[-:]\s+(.*)$`)
+	// A level word standing on its own, e.g. "ERROR ...", "[error] ..." or "error: ..." at the
+	// start of the line (but not "INFORMATION", nor "error" in the middle of a message)
+	textLevelPattern = regexp.MustCompile(`(?:^|[\s\[(<|])(CRITICAL|FATAL|ERROR|WARN(?:ING)?|INFO|DEBUG|TRACE)(?:$|[\s\]):>|,-])|\[(?i:(critical|fatal|error|warn|warning|info|debug|trace))\]|^(?i:(critical|fatal|error|warn|warning|info|debug|trace)):`)
+)
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+	"2006/01/02 15:04:05.999999999",
+	"2006/01/02 15:04:05",
+}
+
+// ParseLogLine detects the format of a log line and extracts its fields. Lines in no
+// known format are treated as text, with the level taken from a standalone level word.
+func ParseLogLine(line string) ParsedLog {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		if p, ok := parseJSONLog(trimmed); ok {
+			return p
+		}
+	}
+	if m := klogPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{
+			Format:    LogFormatKlog,
+			Level:     NormalizeLogLevel(m[1]),
+			Timestamp: klogTimestamp(m[2], m[3], m[4]),
+			Logger:    m[5],
+			Message:   m[6],
+		}
+	}
+	if m := zapPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{Format: LogFormatGo, Level: NormalizeLogLevel(m[2]), Timestamp: parseLogTime(m[1]), Logger: m[3], Message: m[4]}
+	}
+	if m := pythonPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{Format: LogFormatPython, Timestamp: parseLogTime(m[1]), Logger: m[2], Level: NormalizeLogLevel(m[3]), Message: m[4]}
+	}
+	if m := pythonBasicPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{Format: LogFormatPython, Level: NormalizeLogLevel(m[1]), Logger: m[2], Message: strings.TrimSpace(m[3])}
+	}
+	if m := javaPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{Format: LogFormatJava, Timestamp: parseLogTime(m[1]), Level: NormalizeLogLevel(m[2]), Logger: m[3], Message: m[4]}
+	}
+	if p, ok := parseLogfmtLog(trimmed); ok {
+		return p
+	}
+	if m := goStdPattern.FindStringSubmatch(line); m != nil {
+		return ParsedLog{Format: LogFormatGo, Timestamp: parseLogTime(m[1]), Level: textLevel(m[2]), Message: m[2]}
+	}
+	return ParsedLog{Format: LogFormatText, Level: textLevel(line), Message: line}
+}
+
+func parseJSONLog(line string) (ParsedLog, bool) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return ParsedLog{}, false
+	}
+	p := ParsedLog{Format: LogFormatJSON}
+	if v, ok := firstField(fields, "level", "lvl", "severity", "log.level", "levelname", "loglevel"); ok {
+		if n, isNum := v.(float64); isNum {
+			p.Level = numericLevel(n)
+		} else {
+			p.Level = NormalizeLogLevel(fieldString(v))
+		}
+	}
+	if v, ok := firstField(fields, "msg", "message", "@message", "event"); ok {
+		p.Message = fieldString(v)
+	}
+	if v, ok := firstField(fields, "time", "ts", "timestamp", "@timestamp", "asctime"); ok {
+		if n, isNum := v.(float64); isNum {
+			p.Timestamp = epochTime(n)
+		} else {
+			p.Timestamp = parseLogTime(fieldString(v))
+		}
+	}
+	if v, ok := firstField(fields, "logger", "logger_name", "name", "log.logger", "caller"); ok {
+		p.Logger = fieldString(v)
+	}
+	if v, ok := firstField(fields, "error", "err", "exception", "exc_info", "stack_trace", "stacktrace", "error.message"); ok {
+		p.Error = fieldString(v)
+	}
+	return p, true
+}
+
+func firstField(fields map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func fieldString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// parseLogfmtLog accepts key=value lines with at least two pairs, one of them a level or message
+func parseLogfmtLog(line string) (ParsedLog, bool) {
+	fields, ok := parseLogfmt(line)
+	if !ok {
+		return ParsedLog{}, false
+	}
+	_, hasLevel := firstString(fields, "level", "lvl", "severity")
+	_, hasMsg := firstString(fields, "msg", "message")
+	if !hasLevel && !hasMsg {
+		return ParsedLog{}, false
+	}
+	p := ParsedLog{Format: LogFormatLogfmt}
+	if v, ok := firstString(fields, "level", "lvl", "severity"); ok {
+		p.Level = NormalizeLogLevel(v)
+	}
+	p.Message, _ = firstString(fields, "msg", "message")
+	if v, ok := firstString(fields, "time", "ts", "timestamp", "t"); ok {
+		p.Timestamp = parseLogTime(v)
+	}
+	p.Logger, _ = firstString(fields, "logger", "component", "caller", "source")
+	p.Error, _ = firstString(fields, "error", "err")
+	return p, true
+}
+
+func firstString(fields map[string]string, keys ...string) (string, bool) {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func parseLogfmt(line string) (map[string]string, bool) {
+	fields := make(map[string]string)
+	pairs := 0
+	rest := line
+	for rest != "" {
+		sep := strings.IndexAny(rest, "= ")
+		switch {
+		case sep == 0:
+			return nil, false
+		case sep < 0:
+			fields[rest] = "" // bare key at the end
+			rest = ""
+			continue
+		case rest[sep] == ' ':
+			fields[rest[:sep]] = "" // bare key
+			rest = strings.TrimLeft(rest[sep:], " ")
+			continue
+		}
+		key := rest[:sep]
+		rest = rest[sep+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, false
+			}
+			v, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			value, rest = v, rest[end+1:]
+		} else if sp := strings.IndexByte(rest, ' '); sp >= 0 {
+			value, rest = rest[:sp], rest[sp:]
+		} else {
+			value, rest = rest, ""
+		}
+		fields[key] = value
+		pairs++
+		rest = strings.TrimLeft(rest, " ")
+	}
+	return fields, pairs >= 2
+}
+
+// closingQuote returns the index of the quote closing the string s starts with, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func textLevel(line string) string {
+	m := textLevelPattern.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	for _, level := range m[1:] {
+		if level != "" {
+			return NormalizeLogLevel(level)
+		}
+	}
+	return ""
+}
+
+// NormalizeLogLevel maps the level names and abbreviations used by common logging
+// libraries to CRITICAL, ERROR, WARN, INFO, DEBUG or TRACE; unknown names give ""
+func NormalizeLogLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "critical", "crit", "fatal", "f", "panic", "dpanic", "emerg", "emergency", "alert":
+		return "CRITICAL"
+	case "error", "err", "e", "severe":
+		return "ERROR"
+	case "warn", "warning", "w":
+		return "WARN"
+	case "info", "information", "informational", "notice", "i":
+		return "INFO"
+	case "debug", "d", "fine", "config":
+		return "DEBUG"
+	case "trace", "t", "finer", "finest":
+		return "TRACE"
+	}
+	return ""
+}
+
+// numericLevel maps bunyan/pino numeric levels
+func numericLevel(n float64) string {
+	switch {
+	case n >= 60:
+		return "CRITICAL"
+	case n >= 50:
+		return "ERROR"
+	case n >= 40:
+		return "WARN"
+	case n >= 30:
+		return "INFO"
+	case n >= 20:
+		return "DEBUG"
+	case n > 0:
+		return "TRACE"
+	}
+	return ""
+}
+
+func parseLogTime(s string) time.Time {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return epochTime(n)
+	}
+	return time.Time{}
+}
+
+// epochTime converts Unix seconds, or milliseconds for values too large to be seconds
+func epochTime(n float64) time.Time {
+	if n > 1e12 {
+		n /= 1000
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*1e9)).UTC()
+}
+
+// klogTimestamp builds a klog header time; klog omits the year, so the current one is assumed
+func klogTimestamp(month, day, clock string) time.Time {
+	t, err := time.Parse("01 02 15:04:05.999999", month+" "+day+" "+clock)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.AddDate(time.Now().UTC().Year(), 0, 0)
+}
+
+// LogLevelSet normalises requested log levels for use with ParsedLog.Level
+func LogLevelSet(levels []string) map[string]bool {
+	set := make(map[string]bool, len(levels))
+	for _, lvl := range levels {
+		if n := NormalizeLogLevel(lvl); n != "" {
+			set[n] = true
+		}
+	}
+	return set
+}
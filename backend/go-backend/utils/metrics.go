@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Scheduler and scan pipeline metrics, registered with the default registry served on /metrics.
+// Label values are restricted to small, fixed sets to keep cardinality bounded.
+var (
+	schedulerQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "scheduler_queue_depth",
+		Help: "Number of due scan jobs waiting for an execution slot.",
+	})
+	schedulerRunningJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "scheduler_running_jobs",
+		Help: "Number of scan jobs currently executing.",
+	})
+	schedulerQueueWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "scheduler_queue_wait_seconds",
+		Help:    "Time a due scan job waited in the queue before it started.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+	})
+	schedulerJobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_job_runs_total",
+		Help: "Scan job runs by outcome (success, failure).",
+	}, []string{"outcome"})
+	schedulerJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduler_job_run_duration_seconds",
+		Help:    "Duration of scan job runs by outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"outcome"})
+	schedulerLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "scheduler_scheduling_lag_seconds",
+		Help:    "Delay between the time a job became due and the time it started.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+	schedulerTriggers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_triggers_total",
+		Help: "Job triggers whose condition matched, by result (enqueued, loop, max_depth, missing_target, already_pending).",
+	}, []string{"result"})
+	logScanPodsScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "logscan_pods_scanned_total",
+		Help: "Pods whose logs were scanned.",
+	})
+	logScanContainersScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "logscan_containers_scanned_total",
+		Help: "Containers whose logs were scanned.",
+	})
+	logScanContainerErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "logscan_container_errors_total",
+		Help: "Container logs that a scan failed to read.",
+	})
+	logWatchFollowers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "logwatch_active_followers",
+		Help: "Container logs currently followed by watch-mode jobs.",
+	})
+	logScanLinesMatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logscan_lines_matched_total",
+		Help: "Log lines matched by scans, by log level.",
+	}, []string{"level"})
+	findingsObserved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "findings_observed_total",
+		Help: "Problems seen by the event and health sources, by source and result (new, duplicate).",
+	}, []string{"source", "result"})
+	incidentsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "incidents_created_total",
+		Help: "Incidents created by scan jobs, by severity and category.",
+	}, []string{"severity", "category"})
+	incidentsSuppressed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "incidents_suppressed_total",
+		Help: "Matched log lines recorded as suppressed by an active silence.",
+	})
+	microserviceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "microservice_request_duration_seconds",
+		Help:    "Latency of calls to the analysis microservices.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service"})
+	microserviceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "microservice_request_errors_total",
+		Help: "Failed calls to the analysis microservices.",
+	}, []string{"service"})
+)
+
+// knownLevels are the log levels reported as-is in metric labels; anything else is "other"
+var knownLevels = map[string]bool{
+	"CRITICAL": true, "FATAL": true, "ERROR": true, "WARN": true, "WARNING": true, "INFO": true, "DEBUG": true, "TRACE": true,
+}
+
+func levelLabel(level string) string {
+	level = strings.ToUpper(level)
+	if knownLevels[level] {
+		return level
+	}
+	return "other"
+}
+
+// maxCategoryLabels caps the distinct incident categories exported; the rest are folded into "other"
+const maxCategoryLabels = 20
+
+var (
+	categoryLabelsMu sync.Mutex
+	categoryLabels   = make(map[string]bool)
+)
+
+func categoryLabel(category string) string {
+	category = IncidentCategoryName(category)
+	categoryLabelsMu.Lock()
+	defer categoryLabelsMu.Unlock()
+	if categoryLabels[category] {
+		return category
+	}
+	if len(categoryLabels) >= maxCategoryLabels {
+		return "other"
+	}
+	categoryLabels[category] = true
+	return category
+}
+
+// IncidentCategoryName returns the name of an incident category. Incidents stored before
+// categories were normalized hold the analyzer's JSON, such as {"category":"Database"} or
+// {"category":{"name":"Database","confidence":0.9}}; plain names are returned as they are.
+func IncidentCategoryName(category string) string {
+	category = strings.TrimSpace(category)
+	if !strings.HasPrefix(category, "{") {
+		if category == "" {
+			return "unknown"
+		}
+		return category
+	}
+	var wrapped map[string]interface{}
+	if err := json.Unmarshal([]byte(category), &wrapped); err != nil {
+		return "unknown"
+	}
+	if name, ok := analyzerCategory(wrapped); ok {
+		return name
+	}
+	return "unknown"
+}
+
+// analyzerCategory returns the category name in a log analyzer result, whose "category"
+// is either a name or an object with one
+func analyzerCategory(result map[string]interface{}) (string, bool) {
+	value := result["category"]
+	if nested, ok := value.(map[string]interface{}); ok {
+		value = nested["name"]
+		if value == nil {
+			value = nested["category"]
+		}
+	}
+	if name, ok := value.(string); ok && strings.TrimSpace(name) != "" {
+		return strings.TrimSpace(name), true
+	}
+	return "", false
+}
+
+func severityLabel(severity string) string {
+	if severity == "" {
+		return "none"
+	}
+	return severity
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/go-backend/models"
+)
+
+// MaxEventLines caps how many lines one multi-line event keeps
+const MaxEventLines = 500
+
+// BuiltinMultilineRules assemble the stack traces of common runtimes. Custom rules are
+// tried before these.
+var BuiltinMultilineRules = []models.MultilineRule{
+	{
+		// java.lang.IllegalStateException: boom / \tat com.example.App.run(App.java:42) / Caused by: ...
+		// The header needs a package-qualified class, so Python's "ValueError: x" is not one
+		Name:         "java",
+		Start:        `^(Exception in thread "[^"]*" )?([A-Za-z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(: |$)`,
+		Continuation: `^\s+at\s|^\s+\.\.\. \d+ (more|common frames omitted)|^Caused by: |^\s+Suppressed: `,
+		Level:        "ERROR",
+		Logged:       true,
+	},
+	{
+		Name:         "python",
+		Start:        `^Traceback \(most recent call last\):`,
+		Continuation: `^$|^\s|^[\w.]+(Error|Exception|Warning|Exit|Interrupt)\b|^During handling of the above exception|^The above exception was the direct cause`,
+		Level:        "ERROR",
+		Logged:       true,
+	},
+	{
+		Name:         "go",
+		Start:        `^(panic: |fatal error: )`,
+		Continuation: `^$|^goroutine \d+ \[|^\s|^[\w./*()\-]+\(.*\)$|^created by |^\[signal |^exit status \d+`,
+		Level:        "CRITICAL",
+	},
+}
+
+type compiledMultilineRule struct {
+	name         string
+	level        string
+	logged       bool
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+}
+
+// MultilineAssembler groups log lines into events using multi-line rules
+type MultilineAssembler struct {
+	rules []compiledMultilineRule
+}
+
+// NewMultilineAssembler compiles the custom rules followed by the built-in ones
+func NewMultilineAssembler(custom []models.MultilineRule) (*MultilineAssembler, error) {
+	a := &MultilineAssembler{}
+	for _, rule := range append(append([]models.MultilineRule(nil), custom...), BuiltinMultilineRules...) {
+		c, err := compileMultilineRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		a.rules = append(a.rules, c)
+	}
+	return a, nil
+}
+
+// ValidateMultilineRules checks custom rules the way NewMultilineAssembler compiles them
+func ValidateMultilineRules(rules []models.MultilineRule) error {
+	for _, rule := range rules {
+		if _, err := compileMultilineRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compileMultilineRule(rule models.MultilineRule) (compiledMultilineRule, error) {
+	c := compiledMultilineRule{name: rule.Name, level: NormalizeLogLevel(rule.Level), logged: rule.Logged}
+	if rule.Continuation == "" {
+		return c, fmt.Errorf("multiline rule %q needs a continuation pattern", rule.Name)
+	}
+	if rule.Level != "" && c.level == "" {
+		return c, fmt.Errorf("multiline rule %q has unknown level %q", rule.Name, rule.Level)
+	}
+	var err error
+	if c.continuation, err = regexp.Compile(rule.Continuation); err != nil {
+		return c, fmt.Errorf("multiline rule %q: invalid continuation pattern: %v", rule.Name, err)
+	}
+	if rule.Start != "" {
+		if c.start, err = regexp.Compile(rule.Start); err != nil {
+			return c, fmt.Errorf("multiline rule %q: invalid start pattern: %v", rule.Name, err)
+		}
+	}
+	return c, nil
+}
+
+// LogEvent is one logical log entry: a single line, or a first line and its continuation lines
+type LogEvent struct {
+	Timestamp time.Time // of the first line
+	Lines     []string
+	Rule      string // the multi-line rule that assembled the event, "" for single lines
+	level     string
+	truncated bool
+}
+
+// Text returns the event's lines joined with newlines
+func (e LogEvent) Text() string {
+	text := strings.Join(e.Lines, "\n")
+	if e.truncated {
+		text += fmt.Sprintf("\n... (truncated after %d lines)", MaxEventLines)
+	}
+	return text
+}
+
+// Parse parses the event's first line. Events started by a rule with a level take that
+// level when the line names none, and the continuation lines become the Error field
+// when the line has no error of its own.
+func (e LogEvent) Parse() ParsedLog {
+	p := ParseLogLine(e.Lines[0])
+	if p.Level == "" {
+		p.Level = e.level
+	}
+	if p.Error == "" && len(e.Lines) > 1 {
+		p.Error = strings.Join(e.Lines[1:], "\n")
+	}
+	return p
+}
+
+// Assemble groups lines (with their timestamps) into events. A line that continues an
+// event started by a rule stays in it even if it would start another rule's event, e.g.
+// the "requests.exceptions.HTTPError: ..." line ending a Python traceback.
+func (a *MultilineAssembler) Assemble(timestamps []time.Time, lines []string) []LogEvent {
+	var events []LogEvent
+	var current *LogEvent
+	var currentRule *compiledMultilineRule
+	for i, line := range lines {
+		if currentRule != nil && currentRule.continuation.MatchString(line) {
+			current.append(line)
+			continue
+		}
+		if rule := a.startRule(line); rule != nil {
+			if rule.logged && current != nil && currentRule == nil && loggedAtWarnOrAbove(current.Lines[0]) {
+				// e.g. "ERROR com.example.App - request failed" followed by its exception
+				current.append(line)
+				current.Rule, currentRule = rule.name, rule
+				continue
+			}
+			events = append(events, LogEvent{Timestamp: timestamps[i], Lines: []string{line}, Rule: rule.name, level: rule.level})
+			current, currentRule = &events[len(events)-1], rule
+			continue
+		}
+		if current != nil && currentRule == nil {
+			if rule := a.continuationRule(line); rule != nil {
+				current.append(line)
+				if current.Rule == "" {
+					current.Rule = rule.name
+				}
+				continue
+			}
+		}
+		events = append(events, LogEvent{Timestamp: timestamps[i], Lines: []string{line}})
+		current, currentRule = &events[len(events)-1], nil
+	}
+	return events
+}
+
+// loggedAtWarnOrAbove reports whether a line names a level of WARN or above
+func loggedAtWarnOrAbove(line string) bool {
+	switch ParseLogLine(line).Level {
+	case "WARN", "ERROR", "CRITICAL":
+		return true
+	}
+	return false
+}
+
+func (e *LogEvent) append(line string) {
+	if len(e.Lines) < MaxEventLines {
+		e.Lines = append(e.Lines, line)
+	} else {
+		e.truncated = true
+	}
+}
+
+func (a *MultilineAssembler) startRule(line string) *compiledMultilineRule {
+	for i := range a.rules {
+		if a.rules[i].start != nil && a.rules[i].start.MatchString(line) {
+			return &a.rules[i]
+		}
+	}
+	return nil
+}
+
+// continuationRule returns a rule without a start pattern under which line continues an
+// event no rule started
+func (a *MultilineAssembler) continuationRule(line string) *compiledMultilineRule {
+	for i := range a.rules {
+		if a.rules[i].start == nil && a.rules[i].continuation.MatchString(line) {
+			return &a.rules[i]
+		}
+	}
+	return nil
+}
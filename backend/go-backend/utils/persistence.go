@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"backend/go-backend/logger"
@@ -14,10 +15,17 @@ const MaxConcurrentJobs = 5
 var (
 	JobsFile       = "jobs_data.json"
 	IncidentsFile  = "incidents_data.json"
+	CursorsFile    = "cursors_data.json"
 	jobsMutex      sync.RWMutex
 	incidentsMutex sync.RWMutex
-	jobs           = make(map[string][]models.Job)      // userID -> jobs
-	incidents      = make(map[string][]models.Incident) // userID -> incidents
+	cursorsMutex   sync.RWMutex
+	jobs           = make(map[string][]models.Job)                // userID -> jobs
+	incidents      = make(map[string][]models.Incident)           // userID -> incidents
+	cursors        = make(map[string]map[string]models.LogCursor) // jobID -> "pod/container" -> cursor
+
+	// cursorsSaveMutex orders cursor file writes, so a slower save of an older snapshot
+	// cannot overwrite a newer one
+	cursorsSaveMutex sync.Mutex
 )
 
 // ClearJobs resets the global jobs map (for test isolation)
@@ -30,6 +38,13 @@ func ClearIncidents() {
 	incidents = make(map[string][]models.Incident)
 }
 
+// ClearCursors resets the global log cursor map (for test isolation)
+func ClearCursors() {
+	cursorsMutex.Lock()
+	cursors = make(map[string]map[string]models.LogCursor)
+	cursorsMutex.Unlock()
+}
+
 // LoadJobs loads jobs from the JSON file into memory
 func LoadJobs() error {
 	logger.Logger.Info("Loading jobs from file:", JobsFile)
@@ -185,3 +200,130 @@ func SetJobs(userID string, newJobs []models.Job) {
 	jobs[userID] = newJobs
 	jobsMutex.Unlock()
 }
+
+// UpdateJobs replaces a user's jobs with what update returns for the current ones and
+// persists them asynchronously. The lock is held across update, so jobs added or edited
+// meanwhile are not lost; an error from update leaves the jobs unchanged.
+func UpdateJobs(userID string, update func([]models.Job) ([]models.Job, error)) error {
+	jobsMutex.Lock()
+	userJobs, err := update(append([]models.Job(nil), jobs[userID]...))
+	if err != nil {
+		jobsMutex.Unlock()
+		return err
+	}
+	jobs[userID] = userJobs
+	jobsMutex.Unlock()
+	go func() {
+		if err := SaveJobs(); err != nil {
+			logger.Logger.Error("Error saving jobs in UpdateJobs goroutine:", err)
+		}
+	}()
+	return nil
+}
+
+// CursorKey identifies a container within a job's cursor map
+func CursorKey(pod, container string) string {
+	return pod + "/" + container
+}
+
+// LoadCursors loads per-container log cursors from the JSON file into memory
+func LoadCursors() error {
+	logger.Logger.Info("Loading log cursors from file:", CursorsFile)
+	cursorsMutex.Lock()
+	defer cursorsMutex.Unlock()
+	file, err := os.Open(CursorsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Logger.Info("Cursors file does not exist, initializing empty cursors map")
+			cursors = make(map[string]map[string]models.LogCursor)
+			return nil
+		}
+		logger.Logger.Error("Error opening cursors file:", err)
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Logger.Error("Error closing cursors file:", err)
+		}
+	}()
+	err = json.NewDecoder(file).Decode(&cursors)
+	if err != nil {
+		logger.Logger.Error("Error decoding cursors file:", err)
+	}
+	return err
+}
+
+// SaveCursors saves per-container log cursors from memory to the JSON file. Jobs save
+// their cursors concurrently, so saves are serialized and each one replaces the file
+// through a rename, leaving either the old or the new cursors on disk.
+func SaveCursors() error {
+	cursorsSaveMutex.Lock()
+	defer cursorsSaveMutex.Unlock()
+	cursorsMutex.RLock()
+	data, err := json.MarshalIndent(cursors, "", "  ")
+	cursorsMutex.RUnlock()
+	if err != nil {
+		logger.Logger.Error("Error marshaling cursors:", err)
+		return err
+	}
+	err = writeFileAtomic(CursorsFile, data, 0644)
+	if err != nil {
+		logger.Logger.Error("Error writing cursors file:", err)
+	}
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file next to name and renames it over name
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !os.IsNotExist(err) {
+			logger.Logger.Error("Error removing temporary file:", err)
+		}
+	}()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// GetJobCursors returns a copy of the log cursors for a job, keyed by CursorKey
+func GetJobCursors(jobID string) map[string]models.LogCursor {
+	cursorsMutex.RLock()
+	defer cursorsMutex.RUnlock()
+	result := make(map[string]models.LogCursor, len(cursors[jobID]))
+	for k, c := range cursors[jobID] {
+		result[k] = c
+	}
+	return result
+}
+
+// SetJobCursors replaces the log cursors for a job and persists them
+func SetJobCursors(jobID string, jobCursors map[string]models.LogCursor) error {
+	cursorsMutex.Lock()
+	cursors[jobID] = jobCursors
+	cursorsMutex.Unlock()
+	return SaveCursors()
+}
+
+// DeleteJobCursors drops the log cursors of a deleted job and persists the change asynchronously
+func DeleteJobCursors(jobID string) {
+	cursorsMutex.Lock()
+	delete(cursors, jobID)
+	cursorsMutex.Unlock()
+	go func() {
+		if err := SaveCursors(); err != nil {
+			logger.Logger.Error("Error saving cursors in DeleteJobCursors goroutine:", err)
+		}
+	}()
+}
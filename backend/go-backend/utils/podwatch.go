@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"backend/go-backend/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podWatchIdleFlush is how long a log follower waits for more lines before assembling
+// what it has read, so a stack trace is not split across incidents
+const podWatchIdleFlush = 500 * time.Millisecond
+
+// maxFollowLineBytes bounds a single followed log line
+const maxFollowLineBytes = 1024 * 1024
+
+// podWatcher follows the logs of a watch-mode job's containers. A shared pod informer
+// reports containers as they start, each gets a follower streaming its log, and the
+// follower ends when the container's log does or its pod is deleted.
+type podWatcher struct {
+	s         *Scheduler
+	userID    string
+	job       models.Job
+	clientset kubernetes.Interface
+	started   time.Time
+	assembler *MultilineAssembler
+	logLevels map[string]bool
+
+	mu        sync.Mutex
+	followers map[string]podFollower // container ID -> follower
+	wg        sync.WaitGroup
+}
+
+type podFollower struct {
+	pod    string
+	cancel context.CancelFunc
+}
+
+// watchJobPods runs the job's pod watch until ctx is cancelled, restarting it after
+// setup failures
+func (s *Scheduler) watchJobPods(ctx context.Context, userID string, job models.Job) {
+	for ctx.Err() == nil {
+		err := s.runPodWatch(ctx, userID, job)
+		if ctx.Err() != nil {
+			return
+		}
+		Logger.WithFields(map[string]interface{}{
+			"job":  job.ID,
+			"user": userID,
+		}).Warn("[Scheduler] Pod watch failed, retrying: ", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(eventWatchRetry):
+		}
+	}
+}
+
+func (s *Scheduler) runPodWatch(ctx context.Context, userID string, job models.Job) error {
+	cluster, clientset, err := s.clients.Client(job.Cluster)
+	if err != nil {
+		return err
+	}
+	job.Cluster = cluster
+	assembler, err := NewMultilineAssembler(job.Multiline)
+	if err != nil {
+		return err
+	}
+	w := &podWatcher{
+		s:         s,
+		userID:    userID,
+		job:       job,
+		clientset: clientset,
+		started:   time.Now(),
+		assembler: assembler,
+		logLevels: LogLevelSet(job.LogLevels),
+		followers: make(map[string]podFollower),
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(job.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = job.LabelSelector
+			opts.FieldSelector = job.FieldSelector
+		}))
+	informer := factory.Core().V1().Pods().Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.attach(ctx, pod)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.attach(ctx, pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*corev1.Pod); ok {
+				w.detach(pod.Name)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+	Logger.WithFields(map[string]interface{}{
+		"job":       job.ID,
+		"namespace": job.Namespace,
+	}).Info("[Scheduler] Pod watch started")
+	factory.Start(ctx.Done())
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced && ctx.Err() == nil {
+			return fmt.Errorf("pod informer %v did not sync", informerType)
+		}
+	}
+	<-ctx.Done()
+	factory.Shutdown()
+	w.wg.Wait()
+	return ctx.Err()
+}
+
+// attach starts a follower for every running container of the pod that the job's
+// container filter selects and that has none yet.
+// The pod's workload is resolved afresh when a container starts, as rollouts create
+// owners the watch has not seen.
+func (w *podWatcher) attach(ctx context.Context, pod *corev1.Pod) {
+	if len(w.job.Pods) > 0 && !containsString(w.job.Pods, pod.Name) {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	workload, resolved := "", false
+	for _, container := range ContainerNames(pod, w.job.Containers) {
+		cs := containerStatusFor(pod, container)
+		if cs == nil || cs.State.Running == nil || cs.ContainerID == "" {
+			continue
+		}
+		if _, ok := w.followers[cs.ContainerID]; ok {
+			continue
+		}
+		if !resolved {
+			var targeted bool
+			workload, targeted = NewWorkloadResolver(w.clientset, w.job.Namespace).Targets(w.job, pod, time.Now())
+			if !targeted {
+				return
+			}
+			resolved = true
+		}
+		opts := &corev1.PodLogOptions{Container: cs.Name, Follow: true, Timestamps: true}
+		if cs.State.Running.StartedAt.Time.Before(w.started) {
+			// Running before the watch began: only follow what it writes from now on
+			opts.TailLines = int64Ptr(0)
+		}
+		followCtx, cancel := context.WithCancel(ctx)
+		w.followers[cs.ContainerID] = podFollower{pod: pod.Name, cancel: cancel}
+		w.wg.Add(1)
+		logWatchFollowers.Inc()
+		go func(pod *corev1.Pod, container, containerID string) {
+			defer func() {
+				w.mu.Lock()
+				delete(w.followers, containerID)
+				w.mu.Unlock()
+				cancel()
+				logWatchFollowers.Dec()
+				w.wg.Done()
+			}()
+			w.follow(followCtx, pod, container, workload, opts)
+		}(pod.DeepCopy(), cs.Name, cs.ContainerID)
+	}
+}
+
+// detach stops the followers of a deleted pod
+func (w *podWatcher) detach(pod string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, f := range w.followers {
+		if f.pod == pod {
+			f.cancel()
+		}
+	}
+}
+
+// follow streams one container's log, turning matched events into incidents as soon as
+// the container pauses writing, until the log ends or ctx is cancelled
+func (w *podWatcher) follow(ctx context.Context, pod *corev1.Pod, container, workload string, opts *corev1.PodLogOptions) {
+	stream, err := w.clientset.CoreV1().Pods(w.job.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			Logger.WithFields(map[string]interface{}{
+				"pod":       pod.Name,
+				"container": container,
+			}).Warn("[Scheduler] Failed to follow container log: ", err)
+		}
+		return
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			Logger.Error("Error closing log stream:", err)
+		}
+	}()
+	logScanContainersScanned.Inc()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxFollowLineBytes)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var timestamps []time.Time
+	var batch []string
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		matches := matchLogEvents(w.job, w.assembler, w.logLevels, pod, container, timestamps, batch)
+		timestamps, batch = nil, nil
+		if len(matches) == 0 {
+			return
+		}
+		restart, _ := ContainerRestartFor(pod, container)
+		for i := range matches {
+			matches[i].Workload = workload
+			matches[i].Restart = restart
+		}
+		stored := w.s.storeIncidents(w.userID, w.job, matchIncidents(w.userID, w.job, matches))
+		w.s.fireTriggers(w.userID, w.job, stored, nil)
+	}
+	idle := time.NewTimer(podWatchIdleFlush)
+	defer idle.Stop()
+	for {
+		select {
+		case raw, ok := <-lines:
+			if !ok {
+				if ctx.Err() == nil {
+					flush()
+				}
+				return
+			}
+			ts, line, ok := SplitLogTimestamp(raw)
+			if !ok {
+				continue
+			}
+			timestamps = append(timestamps, ts)
+			batch = append(batch, line)
+			if len(batch) >= MaxEventLines {
+				flush()
+			}
+			idle.Reset(podWatchIdleFlush)
+		case <-idle.C:
+			flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
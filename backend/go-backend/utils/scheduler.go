@@ -5,10 +5,11 @@ import (
 	"time"
 
 	"backend/go-backend/models"
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
-	"io"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -16,9 +17,9 @@ import (
 	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // JobStore abstracts job persistence
@@ -58,25 +59,64 @@ type JobExecutor interface {
 // and allows for dependency injection and better testability.
 type Scheduler struct {
 	stopCh        chan struct{}
-	sem           chan struct{}
+	stopOnce      sync.Once
+	queue         *FairQueue
 	interval      time.Duration
 	jobStore      JobStore
 	incidentStore IncidentStore
 	timeProvider  TimeProvider
 	jobExecutor   JobExecutor
+	clients       ClientFactory // used by the job watches
+	maxChainDepth int
+	// Event and pod watches of the jobs, see syncWatches
+	watchesMu sync.Mutex
+	watches   map[string]*jobWatch
 }
 
+// DefaultMaxChainDepth bounds how many triggered runs a single job run can cascade into
+const DefaultMaxChainDepth = 3
+
 var (
-	schedulerInstance *Scheduler
+	schedulerInstance *Scheduler // the running scheduler, the leader's under leader election
+	schedulerMu       sync.RWMutex
 	schedulerOnce     sync.Once
 	stopOnce          sync.Once // Add this for idempotent StopScheduler
 )
 
+// ActiveScheduler returns the scheduler this replica is running, or nil when it runs
+// none, e.g. while another replica holds the leader Lease
+func ActiveScheduler() *Scheduler {
+	schedulerMu.RLock()
+	defer schedulerMu.RUnlock()
+	return schedulerInstance
+}
+
+func setActiveScheduler(s *Scheduler) {
+	schedulerMu.Lock()
+	schedulerInstance = s
+	schedulerMu.Unlock()
+}
+
+// clearActiveScheduler unpublishes s unless another scheduler replaced it already
+func clearActiveScheduler(s *Scheduler) {
+	schedulerMu.Lock()
+	if schedulerInstance == s {
+		schedulerInstance = nil
+	}
+	schedulerMu.Unlock()
+}
+
 // DefaultJobExecutor implements JobExecutor using the existing RunLogScanJob logic
-// (wraps the current implementation for backward compatibility)
-type DefaultJobExecutor struct{}
+// (wraps the current implementation for backward compatibility). Clients, when set,
+// replaces the cluster registry as the source of Kubernetes clients.
+type DefaultJobExecutor struct {
+	Clients ClientFactory
+}
 
-func (DefaultJobExecutor) Run(userID string, job models.Job) ([]models.Incident, error) {
+func (e DefaultJobExecutor) Run(userID string, job models.Job) ([]models.Incident, error) {
+	if e.Clients != nil {
+		return RunLogScanJobWith(e.Clients, userID, job)
+	}
 	return RunLogScanJob(userID, job)
 }
 
@@ -89,6 +129,11 @@ func (DefaultJobStore) GetJobs() map[string][]models.Job {
 }
 
 func (DefaultJobStore) UpdateJobLastRun(userID string, jobIdx int, t time.Time) {
+	jobsMutex.Lock()
+	defer jobsMutex.Unlock()
+	if jobIdx < 0 || jobIdx >= len(jobs[userID]) {
+		return
+	}
 	jobs[userID][jobIdx].LastRun = t
 }
 
@@ -121,24 +166,35 @@ func NewScheduler(jobStore JobStore, incidentStore IncidentStore, timeProvider T
 	if jobExecutor == nil {
 		jobExecutor = DefaultJobExecutor{}
 	}
+	// Watches read the clusters through the executor's clients
+	var clients ClientFactory
+	if e, ok := jobExecutor.(DefaultJobExecutor); ok {
+		clients = e.Clients
+	}
 	// TODO: Replace with real implementations for jobStore and incidentStore
 	// For now, these are left nil and should be set by the caller or refactored in the future.
 	return &Scheduler{
 		stopCh:        make(chan struct{}),
-		sem:           make(chan struct{}, MaxConcurrentJobs),
+		queue:         NewFairQueue(QuotaConfigFromEnv()),
 		interval:      5 * time.Second, // check interval
 		jobStore:      jobStore,
 		incidentStore: incidentStore,
 		timeProvider:  timeProvider,
 		jobExecutor:   jobExecutor,
+		clients:       ClientsOrRegistry(clients),
+		maxChainDepth: envInt("SCHEDULER_MAX_CHAIN_DEPTH", DefaultMaxChainDepth),
+		watches:       make(map[string]*jobWatch),
 	}
 }
 
-// StartScheduler launches the background job scheduler (call once on startup)
-func StartScheduler() {
+// StartScheduler launches the background job scheduler (call once on startup). Jobs read
+// their clusters through clients; nil uses the cluster registry.
+func StartScheduler(clients ClientFactory) {
 	schedulerOnce.Do(func() {
-		schedulerInstance = NewScheduler(DefaultJobStore{}, DefaultIncidentStore{}, nil, nil)
-		go schedulerInstance.Run()
+		SeedFindingsFromIncidents()
+		s := NewScheduler(DefaultJobStore{}, DefaultIncidentStore{}, nil, DefaultJobExecutor{Clients: clients})
+		setActiveScheduler(s)
+		go s.Run()
 	})
 }
 
@@ -148,40 +204,77 @@ func (s *Scheduler) Run() {
 	for {
 		select {
 		case <-s.stopCh:
+			s.stopWatches()
 			Logger.Info("[Scheduler] Run loop stopped")
 			return
 		default:
 			s.runSchedulingCycle()
-			time.Sleep(s.interval)
+			select {
+			case <-s.stopCh:
+			case <-time.After(s.interval):
+			}
 		}
 	}
 }
 
-// runSchedulingCycle checks all jobs and schedules those that are due
+// Stop ends the scheduler loop; it is safe to call more than once. Queued jobs are not
+// started anymore, and jobs still running are left to finish but their results are
+// dropped: no incidents are stored, no triggers fire and the job's last run is kept.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+// stopped reports whether Stop was called
+func (s *Scheduler) stopped() bool {
+	select {
+	case <-s.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// runSchedulingCycle checks all jobs, enqueues those that are due and dispatches
+// as many as the quotas allow. It never blocks on running jobs.
 func (s *Scheduler) runSchedulingCycle() {
 	Logger.Info("[Scheduler] Checking jobs in runSchedulingCycle")
-	// jobsMutex.RLock() // No longer needed; handled in store if needed
 	jobsMap := s.jobStore.GetJobs()
 	for userID, userJobs := range jobsMap {
-		for i, job := range userJobs {
-			if s.shouldRunJob(job) {
+		for _, job := range userJobs {
+			if s.shouldRunJob(job) && s.queue.Enqueue(userID, job, s.timeProvider.Now()) {
 				Logger.WithFields(map[string]interface{}{
 					"job":           job.ID,
 					"user":          userID,
+					"team":          job.Team,
 					"namespace":     job.Namespace,
 					"pods":          job.Pods,
 					"logLevels":     job.LogLevels,
 					"microservices": job.Microservices,
-				}).Info("[Scheduler] Running job")
-				s.sem <- struct{}{}
-				go func(userID string, job models.Job, jobIdx int) {
-					defer func() { <-s.sem }()
-					s.executeJob(userID, job, jobIdx)
-				}(userID, job, i)
+				}).Info("[Scheduler] Job queued")
 			}
 		}
 	}
-	// jobsMutex.RUnlock() // No longer needed
+	s.syncWatches(jobsMap)
+	s.dispatch()
+}
+
+// dispatch starts queued jobs until the queue is empty or every eligible slot is taken
+func (s *Scheduler) dispatch() {
+	for !s.stopped() {
+		item, ok := s.queue.Next(s.timeProvider.Now())
+		if !ok {
+			return
+		}
+		go func(item QueuedJob) {
+			defer func() {
+				s.queue.Done(item)
+				s.dispatch()
+			}()
+			s.executeJob(item.UserID, item.Job)
+		}(item)
+	}
 }
 
 // shouldRunJob determines if a job is due to run
@@ -197,19 +290,39 @@ func (s *Scheduler) shouldRunJob(job models.Job) bool {
 	return shouldRun
 }
 
-// executeJob runs the log scan and handles incidents and job state
-func (s *Scheduler) executeJob(userID string, job models.Job, jobIdx int) {
+// executeJob runs the log scan, handles incidents and job state, and fires the job's triggers
+func (s *Scheduler) executeJob(userID string, job models.Job) {
 	Logger.WithFields(map[string]interface{}{
-		"job_id":  job.ID,
-		"user_id": userID,
-		"jobIdx":  jobIdx,
+		"job_id":       job.ID,
+		"user_id":      userID,
+		"triggered_by": job.TriggeredBy,
 	}).Info("[Scheduler] Executing job")
+	start := s.timeProvider.Now()
+	if lag := start.Sub(job.LastRun.Add(time.Duration(job.Interval) * time.Second)); lag > 0 && job.TriggeredBy == "" {
+		schedulerLag.Observe(lag.Seconds())
+	}
 	incidents, err := s.jobExecutor.Run(userID, job)
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	schedulerJobRuns.WithLabelValues(outcome).Inc()
+	schedulerJobDuration.WithLabelValues(outcome).Observe(s.timeProvider.Since(start).Seconds())
+	// A scheduler stopped mid-run, e.g. on losing the leader Lease, must not write:
+	// the new leader runs the job again
+	if s.stopped() {
+		Logger.WithFields(map[string]interface{}{
+			"job":  job.ID,
+			"user": userID,
+		}).Warn("[Scheduler] Scheduler stopped while the job ran, dropping its results")
+		return
+	}
 	if err != nil {
 		Logger.WithFields(map[string]interface{}{
 			"job":  job.ID,
 			"user": userID,
 		}).Error("[Scheduler] Job failed: ", err)
+		s.fireTriggers(userID, job, nil, err)
 		return
 	}
 	Logger.WithFields(map[string]interface{}{
@@ -217,10 +330,52 @@ func (s *Scheduler) executeJob(userID string, job models.Job, jobIdx int) {
 		"user":      userID,
 		"incidents": len(incidents),
 	}).Info("[Scheduler] Job produced incidents")
+	stored := s.storeIncidents(userID, job, incidents)
+	s.fireTriggers(userID, job, stored, nil)
+	// Triggered runs are extra scans and leave the target's own schedule alone
+	if job.TriggeredBy != "" {
+		return
+	}
+	// Update last run and save jobs using the store; the job may have moved while queued
+	jobIdx := -1
+	for i, j := range s.jobStore.GetJobs()[userID] {
+		if j.ID == job.ID {
+			jobIdx = i
+			break
+		}
+	}
+	if jobIdx == -1 {
+		return
+	}
+	s.jobStore.UpdateJobLastRun(userID, jobIdx, s.timeProvider.Now())
+	if err := s.jobStore.SaveJobs(); err != nil {
+		Logger.Error("Error saving jobs in executeJob:", err)
+	}
+}
+
+// storeIncidents records the incidents of a job run, marking those covered by an active
+// silence as suppressed, and returns the ones that were stored
+func (s *Scheduler) storeIncidents(userID string, job models.Job, incidents []models.Incident) []models.Incident {
+	var stored []models.Incident
 	for _, inc := range incidents {
+		if s.stopped() {
+			break
+		}
+		if inc.TriggeredBy == "" {
+			inc.TriggeredBy = job.TriggeredBy
+		}
+		// Lines covered by an active silence are recorded as suppressed, not opened;
+		// log incidents were already checked before they were analysed
+		if inc.SilenceID != "" {
+			inc.Status = "Suppressed"
+		} else if silence, ok := FindActiveSilence(userID, inc, s.timeProvider.Now()); ok {
+			inc.Status = "Suppressed"
+			inc.SilenceID = silence.ID
+		}
 		Logger.WithFields(map[string]interface{}{
 			"job":      inc.JobID,
 			"log_line": inc.LogLine,
+			"status":   inc.Status,
 		}).Info("[Scheduler] Incident created")
 		err := s.incidentStore.AddIncident(userID, inc)
 		if err != nil {
@@ -228,13 +383,109 @@ func (s *Scheduler) executeJob(userID string, job models.Job, jobIdx int) {
 				"job":      inc.JobID,
 				"incident": inc.ID,
 			}).Error("[Scheduler] Failed to store incident: ", err)
+			continue
+		}
+		stored = append(stored, inc)
+		if inc.SilenceID != "" {
+			incidentsSuppressed.Inc()
+			continue
 		}
+		incidentsCreated.WithLabelValues(severityLabel(inc.Severity), categoryLabel(inc.Category)).Inc()
 	}
-	// Update last run and save jobs using the store
-	s.jobStore.UpdateJobLastRun(userID, jobIdx, s.timeProvider.Now())
-	if err := s.jobStore.SaveJobs(); err != nil {
-		Logger.Error("Error saving jobs in executeJob:", err)
+	return stored
+}
+
+// fireTriggers enqueues the targets of every trigger whose condition this run met.
+// A trigger is skipped when its target already appears upstream in the chain (a loop)
+// or when the chain would exceed the maximum depth.
+func (s *Scheduler) fireTriggers(userID string, job models.Job, incidents []models.Incident, runErr error) {
+	for _, trigger := range job.Triggers {
+		if !triggerConditionMet(trigger, incidents, runErr) {
+			continue
+		}
+		fields := map[string]interface{}{
+			"job":    job.ID,
+			"target": trigger.TargetJobID,
+			"depth":  len(job.TriggerChain) + 1,
+		}
+		chain := append(append([]string(nil), job.TriggerChain...), job.ID)
+		if len(chain) > s.maxChainDepth {
+			Logger.WithFields(fields).Warn("[Scheduler] Trigger skipped: maximum chain depth reached")
+			schedulerTriggers.WithLabelValues("max_depth").Inc()
+			continue
+		}
+		if containsString(chain, trigger.TargetJobID) {
+			Logger.WithFields(fields).Warn("[Scheduler] Trigger skipped: loop detected")
+			schedulerTriggers.WithLabelValues("loop").Inc()
+			continue
+		}
+		var target *models.Job
+		for _, j := range s.jobStore.GetJobs()[userID] {
+			if j.ID == trigger.TargetJobID {
+				target = &j
+				break
+			}
+		}
+		if target == nil {
+			Logger.WithFields(fields).Warn("[Scheduler] Trigger skipped: target job not found")
+			schedulerTriggers.WithLabelValues("missing_target").Inc()
+			continue
+		}
+		run := applyTriggerParams(*target, trigger.Params)
+		run.TriggeredBy = job.ID
+		run.TriggerChain = chain
+		if !s.queue.Enqueue(userID, run, s.timeProvider.Now()) {
+			Logger.WithFields(fields).Info("[Scheduler] Trigger skipped: target already queued or running")
+			schedulerTriggers.WithLabelValues("already_pending").Inc()
+			continue
+		}
+		Logger.WithFields(fields).Info("[Scheduler] Trigger fired")
+		schedulerTriggers.WithLabelValues("enqueued").Inc()
+	}
+}
+
+// severityRank orders incident severities for trigger thresholds
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+func triggerConditionMet(trigger models.JobTrigger, incidents []models.Incident, runErr error) bool {
+	switch trigger.Condition {
+	case models.TriggerOnFailure:
+		return runErr != nil
+	case models.TriggerOnIncident:
+		if runErr != nil {
+			return false
+		}
+		minRank := severityRank[strings.ToLower(trigger.MinSeverity)]
+		for _, inc := range incidents {
+			if inc.SilenceID == "" && severityRank[strings.ToLower(inc.Severity)] >= minRank {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyTriggerParams returns a copy of the target job with the trigger's overrides applied
+func applyTriggerParams(job models.Job, params models.TriggerParams) models.Job {
+	if params.Namespace != "" {
+		job.Namespace = params.Namespace
+	}
+	if len(params.LogLevels) > 0 {
+		job.LogLevels = append([]string(nil), params.LogLevels...)
+	}
+	if len(params.Pods) > 0 {
+		job.Pods = append([]string(nil), params.Pods...)
+	}
+	return job
+}
+
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
 	}
+	return false
 }
 
 // RunLogScanJobFunc is the function type for running a log scan job
@@ -242,79 +493,208 @@ var RunLogScanJob = runLogScanJobImpl
 
 // runLogScanJobImpl is the real implementation
 func runLogScanJobImpl(userID string, job models.Job) ([]models.Incident, error) {
-	clientset, err := getK8sClient()
+	return RunLogScanJobWith(RegistryClients{}, userID, job)
+}
+
+// RunLogScanJobWith runs a job against the cluster client handed out by clients
+func RunLogScanJobWith(clients ClientFactory, userID string, job models.Job) ([]models.Incident, error) {
+	cluster, clientset, err := clients.Client(job.Cluster)
 	if err != nil {
 		return nil, err
 	}
+	job.Cluster = cluster // recorded on every match
+	var incidents []models.Incident
+	// Watch-mode jobs follow their logs continuously; only triggered runs poll them
+	if JobHasSource(job, models.JobSourceLogs) && (job.Mode != models.JobModeWatch || job.TriggeredBy != "") {
+		logIncidents, err := scanJobLogs(userID, job, clientset)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, logIncidents...)
+	}
+	if JobHasSource(job, models.JobSourceEvents) {
+		events, err := ListWarningEvents(clientset, job.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, eventIncidents(userID, job, events)...)
+	}
+	if JobHasSource(job, models.JobSourceHealth) {
+		findings, err := checkJobHealth(clientset, job, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, findingIncidents(userID, job, findings)...)
+	}
+	return incidents, nil
+}
 
-	podsToScan, err := getPodsToScan(clientset, job)
+// scanJobLogs reads the job's container logs from their cursors and turns matched
+// lines into incidents
+func scanJobLogs(userID string, job models.Job, clientset kubernetes.Interface) ([]models.Incident, error) {
+	workloads := NewWorkloadResolver(clientset, job.Namespace)
+	pods, scanErrs, err := getPodsToScan(clientset, job, workloads)
 	if err != nil {
 		return nil, err
 	}
 
-	logLevels := make(map[string]bool)
-	for _, lvl := range job.LogLevels {
-		logLevels[strings.ToUpper(lvl)] = true
+	// Triggered runs may scan with overridden settings, so they read recent lines
+	// without touching the cursors of the job's regular schedule
+	cursors := map[string]models.LogCursor{}
+	if job.TriggeredBy == "" {
+		cursors = GetJobCursors(job.ID)
 	}
-
-	logs, err := getLogsForPods(clientset, job.Namespace, podsToScan, logLevels)
+	logs, nextCursors, containerErrs, err := getLogsForPods(clientset, job, workloads, pods, cursors)
 	if err != nil {
 		return nil, err
 	}
+	for _, scanErr := range append(scanErrs, containerErrs...) {
+		Logger.WithFields(map[string]interface{}{
+			"job":       job.ID,
+			"pod":       scanErr.Pod,
+			"container": scanErr.Container,
+		}).Warn("[RunLogScanJob] Container not scanned: ", scanErr.Error)
+	}
+	if job.TriggeredBy == "" {
+		defer func() {
+			if err := SetJobCursors(job.ID, nextCursors); err != nil {
+				Logger.Error("Error saving log cursors in RunLogScanJob:", err)
+			}
+		}()
+	}
 
 	Logger.WithField("matched_logs", len(logs)).Info("[RunLogScanJob] Total matched logs")
-	if len(logs) == 0 {
-		return nil, nil
-	}
+	return matchIncidents(userID, job, logs), nil
+}
 
+// matchIncidents turns matched log lines into incidents, analysed by the job's microservices
+// unless an active silence covers them
+func matchIncidents(userID string, job models.Job, logs []models.LogMatch) []models.Incident {
+	// Only call selected microservices
+	ms := make(map[string]bool)
+	for _, m := range job.Microservices {
+		ms[m] = true
+	}
 	var incidents []models.Incident
-	for _, logLine := range logs {
-		// Only call selected microservices
-		ms := make(map[string]bool)
-		for _, m := range job.Microservices {
-			ms[m] = true
-		}
-		analyzeResult, predictResult, kbResult, recResult := callMicroservicesForLog(logLine, ms)
-		// Create Incident
-		title := job.Name
-		service := job.Namespace
-		severity := ""
-		if strings.Contains(logLine, "CRITICAL") {
-			severity = "Critical"
-		} else if strings.Contains(logLine, "ERROR") {
-			severity = "High"
-		} else if strings.Contains(logLine, "WARN") {
-			severity = "Medium"
-		} else if strings.Contains(logLine, "INFO") {
-			severity = "Low"
-		}
-		status := "Open"
-		category := "General"
-		if analyzeResult["category"] != nil {
-			category = toString(map[string]interface{}{"category": analyzeResult["category"]})
-		}
-		created := time.Now()
-		resolutionTime := 0.0 // Not resolved yet
-		incident := models.Incident{
+	for _, match := range logs {
+		inc := models.Incident{
 			ID:             uuid.New().String(),
 			UserID:         userID,
 			JobID:          job.ID,
-			Timestamp:      created,
-			LogLine:        logLine,
-			Analysis:       toString(analyzeResult),
-			RootCause:      toString(predictResult),
-			Knowledge:      toString(kbResult),
-			Action:         toString(recResult),
-			Title:          title,
-			Service:        service,
-			Severity:       severity,
-			Status:         status,
-			Category:       category,
-			ResolutionTime: resolutionTime,
-		}
-		incidents = append(incidents, incident)
+			Timestamp:      match.Timestamp,
+			DetectedAt:     time.Now(),
+			LogLine:        match.Line,
+			Cluster:        match.Cluster,
+			Namespace:      match.Namespace,
+			Pod:            match.Pod,
+			Container:      match.Container,
+			Node:           match.Node,
+			Workload:       match.Workload,
+			Level:          match.Level,
+			MatchRule:      match.Rule,
+			Previous:       match.Previous,
+			Restart:        match.Restart,
+			TriggeredBy:    job.TriggeredBy,
+			Title:          job.Name,
+			Service:        job.Namespace,
+			Severity:       severityForLevel(match.Level),
+			Status:         "Open",
+			Category:       "General",
+			ResolutionTime: 0.0, // Not resolved yet
+		}
+		// A silenced line is recorded as suppressed without being analysed, so a
+		// silenced flood does not cost a round of microservice calls per line
+		if silence, ok := FindActiveSilence(userID, inc, inc.DetectedAt); ok {
+			inc.Status = "Suppressed"
+			inc.SilenceID = silence.ID
+			incidents = append(incidents, inc)
+			continue
+		}
+		analyzeResult, predictResult, kbResult, recResult := callMicroservicesForLog(match.Line, ms)
+		if category, ok := analyzerCategory(analyzeResult); ok {
+			inc.Category = category
+		}
+		inc.Analysis = toString(analyzeResult)
+		inc.RootCause = toString(predictResult)
+		inc.Knowledge = toString(kbResult)
+		inc.Action = toString(recResult)
+		incidents = append(incidents, inc)
 	}
-	return incidents, nil
+	return incidents
+}
+
+// severityForLevel derives an incident severity from a normalised log level
+func severityForLevel(level string) string {
+	switch level {
+	case "CRITICAL":
+		return "Critical"
+	case "ERROR":
+		return "High"
+	case "WARN":
+		return "Medium"
+	case "INFO":
+		return "Low"
+	}
+	return ""
+}
+
+// PreviewAnalyzeLimit caps how many matched lines a preview sends to the microservices
+const PreviewAnalyzeLimit = 20
+
+// PreviewMatch is a log line a job definition would turn into an incident
+type PreviewMatch struct {
+	models.LogMatch
+	Severity  string                 `json:"severity"`
+	Analysis  map[string]interface{} `json:"analysis,omitempty"`
+	RootCause map[string]interface{} `json:"root_cause,omitempty"`
+	Knowledge map[string]interface{} `json:"knowledge,omitempty"`
+	Action    map[string]interface{} `json:"action,omitempty"`
+}
+
+// JobPreview is the dry-run result of a job definition
+type JobPreview struct {
+	Pods    []string       `json:"pods"`
+	Matches []PreviewMatch `json:"matches"`
+	// Errors lists the pods and containers whose logs could not be read
+	Errors []models.ScanError `json:"errors,omitempty"`
+}
+
+// PreviewLogScanJob resolves the pods a job would target through clients, the cluster
+// registry when nil, and returns the lines it would match, optionally with microservice
+// analysis. Nothing is persisted: no incidents are created and no job state or log
+// cursors are updated.
+func PreviewLogScanJob(clients ClientFactory, job models.Job, analyze bool) (JobPreview, error) {
+	cluster, clientset, err := ClientsOrRegistry(clients).Client(job.Cluster)
+	if err != nil {
+		return JobPreview{}, err
+	}
+	job.Cluster = cluster // recorded on every match
+	workloads := NewWorkloadResolver(clientset, job.Namespace)
+	pods, scanErrs, err := getPodsToScan(clientset, job, workloads)
+	if err != nil {
+		return JobPreview{}, err
+	}
+	// Start without cursors, as a freshly created job would
+	logs, _, containerErrs, err := getLogsForPods(clientset, job, workloads, pods, map[string]models.LogCursor{})
+	if err != nil {
+		return JobPreview{}, err
+	}
+	ms := make(map[string]bool)
+	for _, m := range job.Microservices {
+		ms[m] = true
+	}
+	preview := JobPreview{Pods: []string{}, Matches: []PreviewMatch{}, Errors: append(scanErrs, containerErrs...)}
+	for _, pod := range pods {
+		preview.Pods = append(preview.Pods, pod.Name)
+	}
+	for i, line := range logs {
+		match := PreviewMatch{LogMatch: line, Severity: severityForLevel(line.Level)}
+		if analyze && i < PreviewAnalyzeLimit {
+			match.Analysis, match.RootCause, match.Knowledge, match.Action = callMicroservicesForLog(line.Line, ms)
+		}
+		preview.Matches = append(preview.Matches, match)
+	}
+	return preview, nil
 }
 
 // Helper to call microservices for a log line
@@ -327,7 +707,7 @@ func callMicroservicesForLog(logLine string, ms map[string]bool) (analyzeResult,
 		analyzerURL := os.Getenv("LOG_ANALYZER_URL")
 		analyzeReq := map[string]interface{}{"logs": []string{logLine}}
 		analyzeBody, _ := json.Marshal(analyzeReq)
-		analyzeResp, err := http.Post(analyzerURL, "application/json", bytes.NewReader(analyzeBody))
+		analyzeResp, err := postToMicroservice("log_analyzer", analyzerURL, analyzeBody)
 		if err == nil {
 			defer func() {
 				if err := analyzeResp.Body.Close(); err != nil {
@@ -342,7 +722,7 @@ func callMicroservicesForLog(logLine string, ms map[string]bool) (analyzeResult,
 	if ms["root_cause_predictor"] {
 		predictorURL := os.Getenv("ROOT_CAUSE_PREDICTOR_URL")
 		predictBody, _ := json.Marshal(map[string]interface{}{"logs": []string{logLine}})
-		predictResp, err := http.Post(predictorURL, "application/json", bytes.NewReader(predictBody))
+		predictResp, err := postToMicroservice("root_cause_predictor", predictorURL, predictBody)
 		if err == nil {
 			defer func() {
 				if err := predictResp.Body.Close(); err != nil {
@@ -358,7 +738,7 @@ func callMicroservicesForLog(logLine string, ms map[string]bool) (analyzeResult,
 		kbURL := os.Getenv("KNOWLEDGE_BASE_URL")
 		kbReq := map[string]interface{}{"query": predictResult["root_cause"]}
 		kbBody, _ := json.Marshal(kbReq)
-		kbResp, err := http.Post(kbURL, "application/json", bytes.NewReader(kbBody))
+		kbResp, err := postToMicroservice("knowledge_base", kbURL, kbBody)
 		if err == nil {
 			defer func() {
 				if err := kbResp.Body.Close(); err != nil {
@@ -374,7 +754,7 @@ func callMicroservicesForLog(logLine string, ms map[string]bool) (analyzeResult,
 		recommenderURL := os.Getenv("ACTION_RECOMMENDER_URL")
 		recReq := map[string]interface{}{"root_cause": predictResult["root_cause"]}
 		recBody, _ := json.Marshal(recReq)
-		recResp, err := http.Post(recommenderURL, "application/json", bytes.NewReader(recBody))
+		recResp, err := postToMicroservice("action_recommender", recommenderURL, recBody)
 		if err == nil {
 			defer func() {
 				if err := recResp.Body.Close(); err != nil {
@@ -389,82 +769,370 @@ func callMicroservicesForLog(logLine string, ms map[string]bool) (analyzeResult,
 	return
 }
 
-// Helper to get Kubernetes client
-func getK8sClient() (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
-	config, err = rest.InClusterConfig()
+// postToMicroservice POSTs a JSON body to an analysis microservice, recording latency and errors
+func postToMicroservice(service, url string, body []byte) (*http.Response, error) {
+	start := time.Now()
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	microserviceDuration.WithLabelValues(service).Observe(time.Since(start).Seconds())
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		microserviceErrors.WithLabelValues(service).Inc()
+	}
+	return resp, err
+}
+
+// Helper to get pods to scan from a single listing of the job's namespace, narrowed by
+// its selectors, workloads and pod names. Pinned pods that no longer exist are returned
+// as errors.
+func getPodsToScan(clientset kubernetes.Interface, job models.Job, workloads *WorkloadResolver) ([]corev1.Pod, []models.ScanError, error) {
+	pods, err := listJobPods(clientset, job, workloads)
 	if err != nil {
-		kubeconfig := os.Getenv("KUBECONFIG")
-		if kubeconfig == "" {
-			kubeconfig = os.ExpandEnv("$HOME/.kube/config")
+		return nil, nil, err
+	}
+	var missing []models.ScanError
+	for _, name := range job.Pods {
+		found := false
+		for _, pod := range pods {
+			if pod.Name == name {
+				found = true
+				break
+			}
 		}
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, err
+		if !found {
+			missing = append(missing, models.ScanError{Pod: name, Error: "pod not found"})
 		}
 	}
-	return kubernetes.NewForConfig(config)
+	return pods, missing, nil
 }
 
-// Helper to get pods to scan
-func getPodsToScan(clientset *kubernetes.Clientset, job models.Job) ([]string, error) {
-	if len(job.Pods) > 0 {
-		return job.Pods, nil
-	}
-	pds, err := clientset.CoreV1().Pods(job.Namespace).List(context.Background(), metav1.ListOptions{})
+// listJobPods lists the pods matching the job's selectors, narrowed to those of
+// job.Workloads and to job.Pods when set
+func listJobPods(clientset kubernetes.Interface, job models.Job, workloads *WorkloadResolver) ([]corev1.Pod, error) {
+	pds, err := clientset.CoreV1().Pods(job.Namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: job.LabelSelector,
+		FieldSelector: job.FieldSelector,
+	})
 	if err != nil {
 		return nil, err
 	}
-	var podsToScan []string
-	for _, pod := range pds.Items {
-		podsToScan = append(podsToScan, pod.Name)
+	now := time.Now()
+	var pods []corev1.Pod
+	for i := range pds.Items {
+		pod := &pds.Items[i]
+		if len(job.Pods) > 0 && !containsString(job.Pods, pod.Name) {
+			continue
+		}
+		if _, ok := workloads.Targets(job, pod, now); !ok {
+			continue
+		}
+		pods = append(pods, *pod)
+	}
+	return pods, nil
+}
+
+// ValidatePodSelectors checks label and field selectors with the apimachinery parsers
+func ValidatePodSelectors(labelSelector, fieldSelector string) error {
+	if _, err := labels.Parse(labelSelector); err != nil {
+		return fmt.Errorf("invalid label selector: %v", err)
 	}
-	return podsToScan, nil
+	if _, err := fields.ParseSelector(fieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector: %v", err)
+	}
+	return nil
 }
 
-// Helper to get logs for pods
-func getLogsForPods(clientset *kubernetes.Clientset, namespace string, podsToScan []string, logLevels map[string]bool) ([]string, error) {
-	var logs []string
-	for _, podName := range podsToScan {
-		var podObj *corev1.Pod
-		pds, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
-		if err != nil {
-			return nil, err
+// Defaults for log collection, overridable with LOGSCAN_CONCURRENCY and
+// LOGSCAN_LIMIT_BYTES
+const (
+	DefaultLogScanConcurrency = 8
+	DefaultLogScanLimitBytes  = 4 * 1024 * 1024 // per container and run
+)
+
+// containerScan is one container of a log collection and its outcome
+type containerScan struct {
+	pod       *corev1.Pod
+	container string
+	workload  string
+	matches   []models.LogMatch
+	cursor    *models.LogCursor
+	errs      []models.ScanError
+}
+
+// Helper to get logs for pods. Containers are read concurrently by a bounded pool of
+// workers, each from its cursor onwards so a scan returns exactly the lines written since
+// the previous run; a container without a cursor starts from its last 100 lines. A read
+// is capped at LOGSCAN_LIMIT_BYTES and the cursor only advances over what was read, so a
+// container that logged more continues on the next run. When a container restarted since
+// the previous run (or has restarted before its first scan), the unread part of its
+// terminated instance's log is read too. Matches are tagged with the workload owning
+// their pod. It returns the matched lines, the advanced cursors for every container that
+// still exists, and the containers that failed.
+func getLogsForPods(clientset kubernetes.Interface, job models.Job, workloads *WorkloadResolver, pods []corev1.Pod, prevCursors map[string]models.LogCursor) ([]models.LogMatch, map[string]models.LogCursor, []models.ScanError, error) {
+	assembler, err := NewMultilineAssembler(job.Multiline)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	c := logCollector{
+		clientset:   clientset,
+		job:         job,
+		assembler:   assembler,
+		logLevels:   LogLevelSet(job.LogLevels),
+		prevCursors: prevCursors,
+		limitBytes:  int64(envInt("LOGSCAN_LIMIT_BYTES", DefaultLogScanLimitBytes)),
+	}
+	var scans []*containerScan
+	for i := range pods {
+		pod := &pods[i]
+		logScanPodsScanned.Inc()
+		workload := workloads.WorkloadFor(pod)
+		for _, container := range ContainerNames(pod, job.Containers) {
+			scans = append(scans, &containerScan{pod: pod, container: container, workload: workload})
 		}
-		for _, pod := range pds.Items {
-			if pod.Name == podName {
-				podObj = &pod
-				break
+	}
+
+	workers := envInt("LOGSCAN_CONCURRENCY", DefaultLogScanConcurrency)
+	if workers < 1 {
+		workers = 1
+	}
+	work := make(chan *containerScan)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(scans); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scan := range work {
+				c.scan(scan)
 			}
+		}()
+	}
+	for _, scan := range scans {
+		work <- scan
+	}
+	close(work)
+	wg.Wait()
+
+	var logs []models.LogMatch
+	var scanErrs []models.ScanError
+	nextCursors := make(map[string]models.LogCursor)
+	for _, scan := range scans {
+		for i := range scan.matches {
+			scan.matches[i].Workload = scan.workload
 		}
-		if podObj == nil {
-			continue // pod not found
+		logs = append(logs, scan.matches...)
+		scanErrs = append(scanErrs, scan.errs...)
+		if scan.cursor != nil {
+			nextCursors[CursorKey(scan.pod.Name, scan.container)] = *scan.cursor
 		}
-		for _, c := range podObj.Spec.Containers {
-			logOpts := &corev1.PodLogOptions{Container: c.Name, TailLines: int64Ptr(100)}
-			reqLog := clientset.CoreV1().Pods(namespace).GetLogs(podName, logOpts)
-			stream, err := reqLog.Stream(context.Background())
-			if err != nil {
-				continue
-			}
-			b, err := io.ReadAll(stream)
-			if err == nil {
-				for _, line := range strings.Split(string(b), "\n") {
-					for lvl := range logLevels {
-						if strings.Contains(line, lvl) {
-							logs = append(logs, line)
-							break
-						}
-					}
-				}
+	}
+	return logs, nextCursors, scanErrs, nil
+}
+
+// logCollector holds what the workers of one getLogsForPods call share; it is only read
+type logCollector struct {
+	clientset   kubernetes.Interface
+	job         models.Job
+	assembler   *MultilineAssembler
+	logLevels   map[string]bool
+	prevCursors map[string]models.LogCursor
+	limitBytes  int64
+}
+
+// scan reads one container's new log lines, and those of its terminated previous
+// instance when it restarted, recording matches, the advanced cursor and any errors
+func (c *logCollector) scan(scan *containerScan) {
+	pod, container := scan.pod, scan.container
+	key := CursorKey(pod.Name, container)
+	containerID := containerIDFor(pod, container)
+	if containerID == "" {
+		return // container has not started yet
+	}
+	fail := func(what string, err error) {
+		logScanContainerErrors.Inc()
+		scan.errs = append(scan.errs, models.ScanError{Pod: pod.Name, Container: container, Error: what + ": " + err.Error()})
+	}
+	restart, previousID := ContainerRestartFor(pod, container)
+	cursor, hasCursor := c.prevCursors[key]
+	if restart != nil && (!hasCursor || cursor.ContainerID != containerID) {
+		// The error that made the container crash is in its terminated instance's log
+		prevOpts := &corev1.PodLogOptions{Container: container, Timestamps: true, Previous: true, LimitBytes: &c.limitBytes}
+		var since time.Time
+		if hasCursor && previousID != "" && cursor.ContainerID == previousID {
+			since = cursor.LastTimestamp
+			if !since.IsZero() {
+				sinceTime := metav1.NewTime(since)
+				prevOpts.SinceTime = &sinceTime
 			}
-			if err := stream.Close(); err != nil {
-				Logger.Error("Error closing log stream:", err)
+		} else {
+			prevOpts.TailLines = int64Ptr(100)
+		}
+		timestamps, lines, _, err := ReadLogLines(c.clientset, c.job.Namespace, pod.Name, prevOpts)
+		if err != nil {
+			fail("reading previous container log", err)
+		} else {
+			timestamps, lines = linesAfter(timestamps, lines, since)
+			matches := matchLogEvents(c.job, c.assembler, c.logLevels, pod, container, timestamps, lines)
+			for i := range matches {
+				matches[i].Previous = true
+				matches[i].Restart = restart
 			}
+			scan.matches = append(scan.matches, matches...)
 		}
 	}
-	return logs, nil
+	if hasCursor && cursor.ContainerID != containerID {
+		// The container restarted: the new instance's log starts empty, read all of it
+		Logger.WithFields(map[string]interface{}{
+			"pod":       pod.Name,
+			"container": container,
+		}).Info("[RunLogScanJob] Container restarted since last scan, reading new instance from start")
+		cursor = models.LogCursor{ContainerID: containerID}
+	}
+	logOpts := &corev1.PodLogOptions{Container: container, Timestamps: true, LimitBytes: &c.limitBytes}
+	switch {
+	case !hasCursor:
+		logOpts.TailLines = int64Ptr(100)
+	case !cursor.LastTimestamp.IsZero():
+		sinceTime := metav1.NewTime(cursor.LastTimestamp)
+		logOpts.SinceTime = &sinceTime
+	}
+	next := models.LogCursor{ContainerID: containerID, LastTimestamp: cursor.LastTimestamp}
+	logScanContainersScanned.Inc()
+	timestamps, lines, _, err := ReadLogLines(c.clientset, c.job.Namespace, pod.Name, logOpts)
+	if err != nil {
+		fail("reading container log", err)
+		if hasCursor {
+			prev := c.prevCursors[key]
+			scan.cursor = &prev
+		}
+		return
+	}
+	// SinceTime has second precision, so lines already seen may be returned again
+	timestamps, lines = linesAfter(timestamps, lines, cursor.LastTimestamp)
+	if n := len(timestamps); n > 0 && timestamps[n-1].After(next.LastTimestamp) {
+		next.LastTimestamp = timestamps[n-1]
+	}
+	scan.matches = append(scan.matches, matchLogEvents(c.job, c.assembler, c.logLevels, pod, container, timestamps, lines)...)
+	scan.cursor = &next
+}
+
+// matchLogEvents assembles a container's log lines into events, so stack traces and other
+// multi-line events become a single match, and keeps those at one of the job's levels
+func matchLogEvents(job models.Job, assembler *MultilineAssembler, logLevels map[string]bool, pod *corev1.Pod, container string, timestamps []time.Time, lines []string) []models.LogMatch {
+	var matches []models.LogMatch
+	for _, event := range assembler.Assemble(timestamps, lines) {
+		parsed := event.Parse()
+		if !logLevels[parsed.Level] {
+			continue
+		}
+		matches = append(matches, models.LogMatch{
+			Cluster:   job.Cluster,
+			Namespace: job.Namespace,
+			Pod:       pod.Name,
+			Container: container,
+			Node:      pod.Spec.NodeName,
+			Timestamp: event.Timestamp,
+			Level:     parsed.Level,
+			Rule:      "level:" + parsed.Level,
+			Line:      event.Text(),
+			Format:    parsed.Format,
+			Message:   parsed.Message,
+			Logger:    parsed.Logger,
+			Error:     parsed.Error,
+			Multiline: event.Rule,
+		})
+		logScanLinesMatched.WithLabelValues(levelLabel(parsed.Level)).Inc()
+	}
+	return matches
+}
+
+// maxLogLineBytes bounds a single log line read by a scan
+const maxLogLineBytes = 1024 * 1024
+
+// ReadLogLines streams a container log with runtime timestamps (opts.Timestamps must be
+// set) and splits it into lines and their timestamps. When opts.LimitBytes cut the log
+// short, truncated is set and the last line, which may be incomplete, is dropped to be
+// read again next time.
+func ReadLogLines(clientset kubernetes.Interface, namespace, pod string, opts *corev1.PodLogOptions) (timestamps []time.Time, lines []string, truncated bool, err error) {
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(context.Background())
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			Logger.Error("Error closing log stream:", err)
+		}
+	}()
+	var read int64
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineBytes)
+	for scanner.Scan() {
+		read += int64(len(scanner.Bytes())) + 1
+		if ts, line, ok := SplitLogTimestamp(scanner.Text()); ok {
+			timestamps = append(timestamps, ts)
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, false, err
+	}
+	if opts.LimitBytes != nil && read >= *opts.LimitBytes {
+		truncated = true
+		if len(lines) > 0 {
+			timestamps, lines = timestamps[:len(timestamps)-1], lines[:len(lines)-1]
+		}
+	}
+	return timestamps, lines, truncated, nil
+}
+
+// linesAfter drops the lines written at or before t
+func linesAfter(timestamps []time.Time, lines []string, t time.Time) ([]time.Time, []string) {
+	for i, ts := range timestamps {
+		if ts.After(t) {
+			return timestamps[i:], lines[i:]
+		}
+	}
+	return nil, nil
+}
+
+// ContainerRestartFor describes a container that has restarted or whose last instance
+// terminated, with the runtime ID of that terminated instance; it returns nil otherwise
+func ContainerRestartFor(pod *corev1.Pod, container string) (*models.ContainerRestart, string) {
+	cs := containerStatusFor(pod, container)
+	if cs == nil {
+		return nil, ""
+	}
+	terminated := cs.LastTerminationState.Terminated
+	if cs.RestartCount == 0 && terminated == nil {
+		return nil, ""
+	}
+	restart := &models.ContainerRestart{RestartCount: cs.RestartCount}
+	previousID := ""
+	if terminated != nil {
+		exitCode := terminated.ExitCode
+		restart.TerminationReason = terminated.Reason
+		restart.ExitCode = &exitCode
+		previousID = terminated.ContainerID
+	}
+	return restart, previousID
+}
+
+// containerIDFor returns the runtime ID of a container's current instance, or "" if it never started
+func containerIDFor(pod *corev1.Pod, container string) string {
+	if cs := containerStatusFor(pod, container); cs != nil {
+		return cs.ContainerID
+	}
+	return ""
+}
+
+// SplitLogTimestamp splits a line fetched with Timestamps: true into its RFC3339 timestamp and content
+func SplitLogTimestamp(rawLine string) (time.Time, string, bool) {
+	tsStr, line, found := strings.Cut(rawLine, " ")
+	if !found {
+		tsStr = rawLine
+	}
+	ts, err := time.Parse(time.RFC3339Nano, tsStr)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return ts, line, true
 }
 
 func int64Ptr(i int64) *int64 { return &i }
@@ -477,8 +1145,11 @@ func toString(m map[string]interface{}) string {
 // StopScheduler stops the background scheduler (for graceful shutdown)
 func StopScheduler() {
 	stopOnce.Do(func() {
-		if schedulerInstance != nil && schedulerInstance.stopCh != nil {
-			close(schedulerInstance.stopCh)
+		if leaderElectionCancel != nil {
+			leaderElectionCancel()
+		}
+		if s := ActiveScheduler(); s != nil && s.stopCh != nil {
+			s.Stop()
 		}
 	})
 }
@@ -486,7 +1157,9 @@ func StopScheduler() {
 // ResetSchedulerForTest resets the scheduler instance and sync.Once variables for test isolation
 // Only use in tests!
 func ResetSchedulerForTest() {
-	schedulerInstance = nil
+	setActiveScheduler(nil)
+	leaderElectorInstance = nil
+	leaderElectionCancel = nil
 	schedulerOnce = sync.Once{}
 	stopOnce = sync.Once{}
 }
@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/models"
+)
+
+var (
+	SilencesFile  = "silences_data.json"
+	silencesMutex sync.RWMutex
+	silences      = make(map[string][]models.Silence) // userID -> silences
+	// silenceMatchers holds silences with their line pattern compiled, rebuilt whenever
+	// a user's silences are loaded or replaced; guarded by silencesMutex
+	silenceMatchers = make(map[string][]silenceMatcher)
+
+	// silencesSaveMutex orders silence file writes, so a slower save of an older snapshot
+	// cannot overwrite a newer one
+	silencesSaveMutex sync.Mutex
+)
+
+// silenceMatcher is a silence whose line pattern has been compiled
+type silenceMatcher struct {
+	silence models.Silence
+	line    *regexp.Regexp // nil without a line pattern
+}
+
+func newSilenceMatchers(userSilences []models.Silence) []silenceMatcher {
+	matchers := make([]silenceMatcher, 0, len(userSilences))
+	for _, s := range userSilences {
+		m := silenceMatcher{silence: s}
+		if s.LinePattern != "" {
+			re, err := regexp.Compile(s.LinePattern)
+			if err != nil {
+				logger.Logger.Warn("Ignoring silence ", s.ID, " with invalid line_pattern: ", err)
+				continue
+			}
+			m.line = re
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ClearSilences resets the global silences map (for test isolation)
+func ClearSilences() {
+	silencesMutex.Lock()
+	silences = make(map[string][]models.Silence)
+	silenceMatchers = make(map[string][]silenceMatcher)
+	silencesMutex.Unlock()
+}
+
+// LoadSilences loads silences from the JSON file into memory
+func LoadSilences() error {
+	logger.Logger.Info("Loading silences from file:", SilencesFile)
+	silencesMutex.Lock()
+	defer silencesMutex.Unlock()
+	file, err := os.Open(SilencesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Logger.Info("Silences file does not exist, initializing empty silences map")
+			silences = make(map[string][]models.Silence)
+			silenceMatchers = make(map[string][]silenceMatcher)
+			return nil
+		}
+		logger.Logger.Error("Error opening silences file:", err)
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Logger.Error("Error closing silences file:", err)
+		}
+	}()
+	err = json.NewDecoder(file).Decode(&silences)
+	if err != nil {
+		logger.Logger.Error("Error decoding silences file:", err)
+	}
+	silenceMatchers = make(map[string][]silenceMatcher, len(silences))
+	for userID, userSilences := range silences {
+		silenceMatchers[userID] = newSilenceMatchers(userSilences)
+	}
+	return err
+}
+
+// SaveSilences saves silences from memory to the JSON file. Saves are serialized and
+// each one replaces the file through a rename.
+func SaveSilences() error {
+	logger.Logger.Info("Saving silences to file:", SilencesFile)
+	silencesSaveMutex.Lock()
+	defer silencesSaveMutex.Unlock()
+	silencesMutex.RLock()
+	data, err := json.MarshalIndent(silences, "", "  ")
+	silencesMutex.RUnlock()
+	if err != nil {
+		logger.Logger.Error("Error marshaling silences:", err)
+		return err
+	}
+	err = writeFileAtomic(SilencesFile, data, 0644)
+	if err != nil {
+		logger.Logger.Error("Error writing silences file:", err)
+	}
+	return err
+}
+
+// GetSilences returns all silences for a user
+func GetSilences(userID string) []models.Silence {
+	silencesMutex.RLock()
+	defer silencesMutex.RUnlock()
+	return append([]models.Silence(nil), silences[userID]...)
+}
+
+// SetSilences replaces all silences for a user and persists them
+func SetSilences(userID string, userSilences []models.Silence) error {
+	return UpdateSilences(userID, func([]models.Silence) ([]models.Silence, error) {
+		return userSilences, nil
+	})
+}
+
+// UpdateSilences replaces a user's silences with what update returns for the current
+// ones and persists them. The lock is held across update, so concurrent edits are
+// applied one after the other; an error from update leaves the silences unchanged.
+func UpdateSilences(userID string, update func([]models.Silence) ([]models.Silence, error)) error {
+	silencesMutex.Lock()
+	userSilences, err := update(append([]models.Silence(nil), silences[userID]...))
+	if err != nil {
+		silencesMutex.Unlock()
+		return err
+	}
+	silences[userID] = userSilences
+	silenceMatchers[userID] = newSilenceMatchers(userSilences)
+	silencesMutex.Unlock()
+	return SaveSilences()
+}
+
+// ValidateSilence checks that a silence has at least one matcher, a well-formed
+// time window and valid patterns
+func ValidateSilence(s models.Silence) error {
+	if s.Namespace == "" && s.PodPattern == "" && s.JobID == "" && s.Severity == "" && s.LinePattern == "" {
+		return errors.New("at least one of namespace, pod_pattern, job_id, severity or line_pattern is required")
+	}
+	if s.PodPattern != "" {
+		if _, err := path.Match(s.PodPattern, ""); err != nil {
+			return fmt.Errorf("invalid pod_pattern: %v", err)
+		}
+	}
+	if s.LinePattern != "" {
+		if _, err := regexp.Compile(s.LinePattern); err != nil {
+			return fmt.Errorf("invalid line_pattern: %v", err)
+		}
+	}
+	if s.Recurrence == nil {
+		if s.EndsAt.IsZero() {
+			return errors.New("ends_at is required for a one-off silence")
+		}
+	} else if err := validateRecurrence(*s.Recurrence); err != nil {
+		return err
+	}
+	if !s.EndsAt.IsZero() && !s.EndsAt.After(s.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func validateRecurrence(r models.Recurrence) error {
+	if _, _, err := parseClock(r.StartTime); err != nil {
+		return err
+	}
+	if r.DurationMinutes <= 0 || r.DurationMinutes > 24*60 {
+		return errors.New("recurrence duration_minutes must be between 1 and 1440")
+	}
+	for _, d := range r.Weekdays {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("invalid recurrence weekday %q", d)
+		}
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return fmt.Errorf("invalid recurrence timezone %q", r.Timezone)
+	}
+	return nil
+}
+
+func parseClock(hhmm string) (int, int, error) {
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid recurrence start_time %q, expected HH:MM", hhmm)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// SilenceActiveAt reports whether a silence applies at time t
+func SilenceActiveAt(s models.Silence, t time.Time) bool {
+	if !s.StartsAt.IsZero() && t.Before(s.StartsAt) {
+		return false
+	}
+	if !s.EndsAt.IsZero() && !t.Before(s.EndsAt) {
+		return false
+	}
+	if s.Recurrence == nil {
+		return true
+	}
+	return recurrenceActiveAt(*s.Recurrence, t)
+}
+
+func recurrenceActiveAt(r models.Recurrence, t time.Time) bool {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return false
+	}
+	hour, minute, err := parseClock(r.StartTime)
+	if err != nil {
+		return false
+	}
+	local := t.In(loc)
+	duration := time.Duration(r.DurationMinutes) * time.Minute
+	// A window that started yesterday may still be open after midnight
+	for _, offset := range []int{0, -1} {
+		day := local.AddDate(0, 0, offset)
+		start := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+		if !weekdayAllowed(r.Weekdays, start.Weekday()) {
+			continue
+		}
+		if !t.Before(start) && t.Before(start.Add(duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func weekdayAllowed(allowed []string, day time.Weekday) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, d := range allowed {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// SilenceMatches reports whether every matcher of a silence matches the incident
+func SilenceMatches(s models.Silence, inc models.Incident) bool {
+	var line *regexp.Regexp
+	if s.LinePattern != "" {
+		re, err := regexp.Compile(s.LinePattern)
+		if err != nil {
+			return false
+		}
+		line = re
+	}
+	return silenceMatcher{silence: s, line: line}.matches(inc)
+}
+
+func (m silenceMatcher) matches(inc models.Incident) bool {
+	s := m.silence
+	if s.JobID != "" && s.JobID != inc.JobID {
+		return false
+	}
+	namespace := inc.Namespace
+	if namespace == "" {
+		namespace = inc.Service // incidents stored before provenance was recorded
+	}
+	if s.Namespace != "" && s.Namespace != namespace {
+		return false
+	}
+	if s.Severity != "" && !strings.EqualFold(s.Severity, inc.Severity) {
+		return false
+	}
+	if s.PodPattern != "" {
+		if ok, err := path.Match(s.PodPattern, inc.Pod); err != nil || !ok {
+			return false
+		}
+	}
+	if m.line != nil && !m.line.MatchString(inc.LogLine) {
+		return false
+	}
+	return true
+}
+
+// FindActiveSilence returns the first of the user's silences that is active at t and matches the incident
+func FindActiveSilence(userID string, inc models.Incident, t time.Time) (models.Silence, bool) {
+	silencesMutex.RLock()
+	defer silencesMutex.RUnlock()
+	for _, m := range silenceMatchers[userID] {
+		if SilenceActiveAt(m.silence, t) && m.matches(inc) {
+			return m.silence, true
+		}
+	}
+	return models.Silence{}, false
+}
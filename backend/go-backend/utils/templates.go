@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"backend/go-backend/logger"
+	"backend/go-backend/models"
+)
+
+var (
+	TemplatesFile  = "job_templates_data.json"
+	templatesMutex sync.RWMutex
+	templates      = make(map[string][]models.JobTemplate) // userID -> templates
+
+	// templatesSaveMutex orders template file writes, so a slower save of an older
+	// snapshot cannot overwrite a newer one
+	templatesSaveMutex sync.Mutex
+)
+
+// ClearTemplates resets the global job templates map (for test isolation)
+func ClearTemplates() {
+	templatesMutex.Lock()
+	templates = make(map[string][]models.JobTemplate)
+	templatesMutex.Unlock()
+}
+
+// LoadTemplates loads job templates from the JSON file into memory
+func LoadTemplates() error {
+	logger.Logger.Info("Loading job templates from file:", TemplatesFile)
+	templatesMutex.Lock()
+	defer templatesMutex.Unlock()
+	file, err := os.Open(TemplatesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Logger.Info("Job templates file does not exist, initializing empty templates map")
+			templates = make(map[string][]models.JobTemplate)
+			return nil
+		}
+		logger.Logger.Error("Error opening job templates file:", err)
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Logger.Error("Error closing job templates file:", err)
+		}
+	}()
+	err = json.NewDecoder(file).Decode(&templates)
+	if err != nil {
+		logger.Logger.Error("Error decoding job templates file:", err)
+	}
+	return err
+}
+
+// SaveTemplates saves job templates from memory to the JSON file. Saves are serialized
+// and each one replaces the file through a rename.
+func SaveTemplates() error {
+	logger.Logger.Info("Saving job templates to file:", TemplatesFile)
+	templatesSaveMutex.Lock()
+	defer templatesSaveMutex.Unlock()
+	templatesMutex.RLock()
+	data, err := json.MarshalIndent(templates, "", "  ")
+	templatesMutex.RUnlock()
+	if err != nil {
+		logger.Logger.Error("Error marshaling job templates:", err)
+		return err
+	}
+	err = writeFileAtomic(TemplatesFile, data, 0644)
+	if err != nil {
+		logger.Logger.Error("Error writing job templates file:", err)
+	}
+	return err
+}
+
+// GetTemplates returns all job templates for a user
+func GetTemplates(userID string) []models.JobTemplate {
+	templatesMutex.RLock()
+	defer templatesMutex.RUnlock()
+	return append([]models.JobTemplate(nil), templates[userID]...)
+}
+
+// SetTemplates replaces all job templates for a user and persists them
+func SetTemplates(userID string, userTemplates []models.JobTemplate) error {
+	return UpdateTemplates(userID, func([]models.JobTemplate) ([]models.JobTemplate, error) {
+		return userTemplates, nil
+	})
+}
+
+// UpdateTemplates replaces a user's job templates with what update returns for the current
+// ones and persists them. The lock is held across update, so concurrent edits are
+// applied one after the other; an error from update leaves the templates unchanged.
+func UpdateTemplates(userID string, update func([]models.JobTemplate) ([]models.JobTemplate, error)) error {
+	templatesMutex.Lock()
+	userTemplates, err := update(append([]models.JobTemplate(nil), templates[userID]...))
+	if err != nil {
+		templatesMutex.Unlock()
+		return err
+	}
+	templates[userID] = userTemplates
+	templatesMutex.Unlock()
+	return SaveTemplates()
+}
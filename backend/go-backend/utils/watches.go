@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+
+	"backend/go-backend/models"
+)
+
+// jobWatch is a running watch of one job
+type jobWatch struct {
+	target string // the job definition the watch was started for
+	cancel context.CancelFunc
+}
+
+// watchTarget describes everything about a job a running watch depends on, so that
+// editing the job restarts its watches; the last run time is left out
+func watchTarget(job models.Job) string {
+	job.LastRun = job.CreatedAt
+	b, _ := json.Marshal(job)
+	return string(b)
+}
+
+// syncWatches keeps the long-running watches in line with the jobs: an event watch per
+// job with the events source, so events become incidents as they happen rather than on
+// the job's next run, and a pod watch per watch-mode job scanning logs. Watches of jobs
+// that were deleted or changed are stopped, the latter restarted.
+func (s *Scheduler) syncWatches(jobsMap map[string][]models.Job) {
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+	wanted := make(map[string]bool)
+	ensure := func(key, target string, run func(ctx context.Context)) {
+		wanted[key] = true
+		if w, ok := s.watches[key]; ok {
+			if w.target == target {
+				return
+			}
+			w.cancel()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.watches[key] = &jobWatch{target: target, cancel: cancel}
+		go run(ctx)
+	}
+	for userID, userJobs := range jobsMap {
+		for _, job := range userJobs {
+			target := watchTarget(job)
+			if JobHasSource(job, models.JobSourceEvents) {
+				ensure("events/"+userID+"/"+job.ID, target, func(ctx context.Context) {
+					s.watchJobEvents(ctx, userID, job)
+				})
+			}
+			if job.Mode == models.JobModeWatch && JobHasSource(job, models.JobSourceLogs) {
+				ensure("pods/"+userID+"/"+job.ID, target, func(ctx context.Context) {
+					s.watchJobPods(ctx, userID, job)
+				})
+			}
+		}
+	}
+	for key, w := range s.watches {
+		if !wanted[key] {
+			w.cancel()
+			delete(s.watches, key)
+		}
+	}
+}
+
+// stopWatches cancels every running watch
+func (s *Scheduler) stopWatches() {
+	s.watchesMu.Lock()
+	defer s.watchesMu.Unlock()
+	for key, w := range s.watches {
+		w.cancel()
+		delete(s.watches, key)
+	}
+}
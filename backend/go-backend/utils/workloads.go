@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	"backend/go-backend/models"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CronJobFailedRunWindow is how long after failing the pods of a CronJob's run are
+// still targeted, so the logs of a failed run are scanned before it is cleaned up
+const CronJobFailedRunWindow = 24 * time.Hour
+
+// WorkloadResolver maps pods to the workload that ultimately owns them, following
+// ReplicaSets to their Deployment and Jobs to their CronJob. The owners of a namespace
+// are listed once, on first use; a resolver is not safe for concurrent use.
+type WorkloadResolver struct {
+	clientset kubernetes.Interface
+	namespace string
+	owners    map[string]string // "ReplicaSet/name" or "Job/name" -> owning workload
+	jobs      map[string]*batchv1.Job
+}
+
+func NewWorkloadResolver(clientset kubernetes.Interface, namespace string) *WorkloadResolver {
+	return &WorkloadResolver{clientset: clientset, namespace: namespace}
+}
+
+// WorkloadFor returns the pod's owning workload as "Kind/name", or "" for a bare pod
+func (r *WorkloadResolver) WorkloadFor(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	key := owner.Kind + "/" + owner.Name
+	if owner.Kind == "ReplicaSet" || owner.Kind == "Job" {
+		r.load()
+		if workload, ok := r.owners[key]; ok {
+			return workload
+		}
+	}
+	return key
+}
+
+func (r *WorkloadResolver) load() {
+	if r.owners != nil {
+		return
+	}
+	r.owners = make(map[string]string)
+	add := func(kind string, meta metav1.Object) {
+		if owner := metav1.GetControllerOfNoCopy(meta); owner != nil {
+			r.owners[kind+"/"+meta.GetName()] = owner.Kind + "/" + owner.Name
+		}
+	}
+	replicaSets, err := r.clientset.AppsV1().ReplicaSets(r.namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		Logger.Warn("[Workloads] Failed to list ReplicaSets for workload lookup: ", err)
+	} else {
+		for i := range replicaSets.Items {
+			add("ReplicaSet", &replicaSets.Items[i])
+		}
+	}
+	jobs, err := r.clientset.BatchV1().Jobs(r.namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		Logger.Warn("[Workloads] Failed to list Jobs for workload lookup: ", err)
+	} else {
+		r.jobs = make(map[string]*batchv1.Job, len(jobs.Items))
+		for i := range jobs.Items {
+			add("Job", &jobs.Items[i])
+			r.jobs[jobs.Items[i].Name] = &jobs.Items[i]
+		}
+	}
+}
+
+// Targets reports whether the job targets the pod, which it does when the job names no
+// workloads or one of them owns the pod, and returns the pod's owning workload. A
+// CronJob owns the pods of its running Jobs and of those that failed within
+// CronJobFailedRunWindow; the pods of succeeded runs are not targeted.
+func (r *WorkloadResolver) Targets(job models.Job, pod *corev1.Pod, now time.Time) (string, bool) {
+	workload := r.WorkloadFor(pod)
+	if len(job.Workloads) == 0 {
+		return workload, true
+	}
+	for _, ref := range job.Workloads {
+		if ref.String() != workload {
+			continue
+		}
+		if ref.Kind == models.WorkloadCronJob {
+			return workload, r.recentRun(metav1.GetControllerOf(pod), now)
+		}
+		return workload, true
+	}
+	return workload, false
+}
+
+// recentRun reports whether the Job controlling a CronJob pod is running or recently failed
+func (r *WorkloadResolver) recentRun(owner *metav1.OwnerReference, now time.Time) bool {
+	run := r.jobs[owner.Name]
+	if run == nil {
+		return true // created after the Jobs were listed
+	}
+	for _, cond := range run.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return false
+		case batchv1.JobFailed:
+			return now.Sub(cond.LastTransitionTime.Time) <= CronJobFailedRunWindow
+		}
+	}
+	return true
+}
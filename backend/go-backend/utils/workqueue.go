@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/go-backend/models"
+)
+
+const (
+	DefaultMaxJobsPerUser = 2
+	DefaultMaxJobsPerTeam = 4
+)
+
+// QuotaConfig bounds how many jobs may run at once, globally and per user/team.
+// A zero limit means unlimited.
+type QuotaConfig struct {
+	MaxConcurrent int
+	PerUser       int
+	PerTeam       int
+}
+
+// QuotaConfigFromEnv reads scheduler quotas from the environment, falling back to defaults
+func QuotaConfigFromEnv() QuotaConfig {
+	return QuotaConfig{
+		MaxConcurrent: envInt("SCHEDULER_MAX_CONCURRENT_JOBS", MaxConcurrentJobs),
+		PerUser:       envInt("SCHEDULER_MAX_JOBS_PER_USER", DefaultMaxJobsPerUser),
+		PerTeam:       envInt("SCHEDULER_MAX_JOBS_PER_TEAM", DefaultMaxJobsPerTeam),
+	}
+}
+
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		Logger.Warnf("[Scheduler] Invalid value %q for %s, using %d", v, key, def)
+		return def
+	}
+	return n
+}
+
+// QueuedJob is a due job waiting for (or holding) an execution slot
+type QueuedJob struct {
+	UserID     string
+	Job        models.Job
+	EnqueuedAt time.Time
+}
+
+// FairQueue is a non-blocking work queue that dispatches jobs round-robin across users
+// while enforcing global, per-user and per-team concurrency limits.
+type FairQueue struct {
+	mu            sync.Mutex
+	quota         QuotaConfig
+	queues        map[string][]QueuedJob // userID -> FIFO of waiting jobs
+	users         []string               // round-robin order of users with waiting jobs
+	next          int
+	pending       map[string]bool // job IDs that are queued or running
+	running       int
+	runningByUser map[string]int
+	runningByTeam map[string]int
+}
+
+// NewFairQueue creates an empty FairQueue with the given quotas
+func NewFairQueue(quota QuotaConfig) *FairQueue {
+	return &FairQueue{
+		quota:         quota,
+		queues:        make(map[string][]QueuedJob),
+		pending:       make(map[string]bool),
+		runningByUser: make(map[string]int),
+		runningByTeam: make(map[string]int),
+	}
+}
+
+// Enqueue adds a job unless it is already queued or running; it never blocks
+func (q *FairQueue) Enqueue(userID string, job models.Job, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending[job.ID] {
+		return false
+	}
+	q.pending[job.ID] = true
+	if len(q.queues[userID]) == 0 {
+		q.users = append(q.users, userID)
+	}
+	q.queues[userID] = append(q.queues[userID], QueuedJob{UserID: userID, Job: job, EnqueuedAt: now})
+	q.updateDepthMetric()
+	return true
+}
+
+// Next hands out the next job that fits within the quotas, visiting users round-robin.
+// The returned job holds a slot until Done is called.
+func (q *FairQueue) Next(now time.Time) (QueuedJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.quota.MaxConcurrent > 0 && q.running >= q.quota.MaxConcurrent {
+		return QueuedJob{}, false
+	}
+	for i := 0; i < len(q.users); i++ {
+		idx := (q.next + i) % len(q.users)
+		userID := q.users[idx]
+		if q.quota.PerUser > 0 && q.runningByUser[userID] >= q.quota.PerUser {
+			continue
+		}
+		for j, item := range q.queues[userID] {
+			team := item.Job.Team
+			if team != "" && q.quota.PerTeam > 0 && q.runningByTeam[team] >= q.quota.PerTeam {
+				continue
+			}
+			q.queues[userID] = append(q.queues[userID][:j:j], q.queues[userID][j+1:]...)
+			if len(q.queues[userID]) == 0 {
+				delete(q.queues, userID)
+				q.users = append(q.users[:idx:idx], q.users[idx+1:]...)
+				q.next = idx
+			} else {
+				q.next = idx + 1
+			}
+			if len(q.users) > 0 {
+				q.next %= len(q.users)
+			} else {
+				q.next = 0
+			}
+			q.running++
+			q.runningByUser[userID]++
+			if team != "" {
+				q.runningByTeam[team]++
+			}
+			q.updateDepthMetric()
+			schedulerQueueWait.Observe(now.Sub(item.EnqueuedAt).Seconds())
+			schedulerRunningJobs.Set(float64(q.running))
+			return item, true
+		}
+	}
+	return QueuedJob{}, false
+}
+
+// Done releases the slot held by a job returned from Next
+func (q *FairQueue) Done(item QueuedJob) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, item.Job.ID)
+	q.running--
+	q.runningByUser[item.UserID]--
+	if q.runningByUser[item.UserID] <= 0 {
+		delete(q.runningByUser, item.UserID)
+	}
+	if team := item.Job.Team; team != "" {
+		q.runningByTeam[team]--
+		if q.runningByTeam[team] <= 0 {
+			delete(q.runningByTeam, team)
+		}
+	}
+	schedulerRunningJobs.Set(float64(q.running))
+}
+
+// Depth returns the number of jobs waiting for a slot
+func (q *FairQueue) Depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth()
+}
+
+// Running returns the number of jobs currently holding a slot
+func (q *FairQueue) Running() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.running
+}
+
+func (q *FairQueue) depth() int {
+	n := 0
+	for _, items := range q.queues {
+		n += len(items)
+	}
+	return n
+}
+
+func (q *FairQueue) updateDepthMetric() {
+	schedulerQueueDepth.Set(float64(q.depth()))
+}
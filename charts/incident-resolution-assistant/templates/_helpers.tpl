@@ -0,0 +1,13 @@
+{{/*
+ServiceAccount the Go backend runs as: goBackend.serviceAccount.name, or go-backend
+when the chart creates it and default otherwise
+*/}}
+{{- define "goBackend.serviceAccountName" -}}
+{{- if .Values.goBackend.serviceAccount.name -}}
+{{ .Values.goBackend.serviceAccount.name }}
+{{- else if .Values.goBackend.serviceAccount.create -}}
+go-backend
+{{- else -}}
+default
+{{- end -}}
+{{- end }}
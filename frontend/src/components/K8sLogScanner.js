@@ -28,6 +28,22 @@ function getLogLevel(line) {
   return null;
 }
 
+// One-line status of a pod for the pod picker, e.g. "Running · 1/2 ready · Deployment/web · 3 restarts"
+function describePod(pod) {
+  const containers = pod.containers || [];
+  const parts = [pod.phase || 'Unknown', `${pod.ready_containers || 0}/${containers.length} ready`];
+  if (pod.owner) parts.push(pod.owner);
+  if (pod.restarts) parts.push(`${pod.restarts} restart${pod.restarts === 1 ? '' : 's'}`);
+  return parts.join(' · ');
+}
+
+// Containers of a pod with their state, e.g. "app (running), sidecar (waiting: CrashLoopBackOff)"
+function describeContainers(pod) {
+  return (pod.containers || [])
+    .map(c => `${c.name} (${c.state}${c.reason ? `: ${c.reason}` : ''})`)
+    .join(', ');
+}
+
 function LogsPanel({ logs, logFilter, setLogFilter, showAllLogs, setShowAllLogs, logsContainerRef, getLogLevel, LOG_LEVEL_COLORS }) {
   if (!logs || logs.length === 0 || (Array.isArray(logs) && logs[0] === "")) {
     return <div className="no-data">No logs available for this scan.</div>;
@@ -165,7 +181,7 @@ const K8sLogScanner = () => {
       api.getK8sPods(selectedCluster, ns).then(pods => {
         setAvailablePods(pods);
         // If creating a new job, default to all pods selected
-        setJobForm(prev => ({ ...prev, pods: pods }));
+        setJobForm(prev => ({ ...prev, pods: pods.map(pod => pod.name) }));
       }).catch(() => setAvailablePods([]));
     }
   }, [selectedCluster, selectedNamespaces, showJobForm]);
@@ -470,22 +486,32 @@ const K8sLogScanner = () => {
                         )}
                       </Typography>
                     ) : (
-                      <FormGroup row>
+                      <FormGroup>
                         {availablePods.map(pod => (
                           <FormControlLabel
-                            key={pod}
+                            key={pod.name}
                             control={
                               <Checkbox
-                                checked={jobForm.pods.includes(pod)}
+                                checked={jobForm.pods.includes(pod.name)}
                                 onChange={() => setJobForm(prev => ({
                                   ...prev,
-                                  pods: prev.pods.includes(pod)
-                                    ? prev.pods.filter(p => p !== pod)
-                                    : [...prev.pods, pod],
+                                  pods: prev.pods.includes(pod.name)
+                                    ? prev.pods.filter(p => p !== pod.name)
+                                    : [...prev.pods, pod.name],
                                 }))}
                               />
                             }
-                            label={pod}
+                            label={
+                              <Box>
+                                <Typography variant="body2">{pod.name}</Typography>
+                                <Typography variant="caption" color={pod.ready ? 'text.secondary' : 'warning.main'} display="block">
+                                  {describePod(pod)}
+                                </Typography>
+                                <Typography variant="caption" color="text.secondary" display="block">
+                                  {describeContainers(pod)}
+                                </Typography>
+                              </Box>
+                            }
                           />
                         ))}
                       </FormGroup>
@@ -700,6 +726,22 @@ const K8sLogScanner = () => {
       {scanResults && Array.isArray(scanResults.results) ? (
         <div className="scan-results">
           <h3>Scan Results</h3>
+          {Array.isArray(scanResults.errors) && scanResults.errors.some(e => !e.truncated) && (
+            <Alert severity="warning" sx={{ mb: 2 }}>
+              Could not read the logs of {scanResults.errors.filter(e => !e.truncated).length} container(s):
+              {scanResults.errors.filter(e => !e.truncated).map((e, idx) => (
+                <div key={idx}>{[e.namespace, e.pod, e.container].filter(Boolean).join('/')}: {e.error}</div>
+              ))}
+            </Alert>
+          )}
+          {Array.isArray(scanResults.errors) && scanResults.errors.some(e => e.truncated) && (
+            <Alert severity="info" sx={{ mb: 2 }}>
+              Only part of the time range was read for {scanResults.errors.filter(e => e.truncated).length} container(s); narrow the range to see the newest lines:
+              {scanResults.errors.filter(e => e.truncated).map((e, idx) => (
+                <div key={idx}>{[e.namespace, e.pod, e.container].filter(Boolean).join('/')}: {e.error}</div>
+              ))}
+            </Alert>
+          )}
           {Array.isArray(scanResults?.results) ? (
             scanResults.results.length === 0 ? (
               <div className="no-data">No log events found for this scan.</div>
@@ -50,7 +50,21 @@ describe('K8sLogScanner scheduled jobs', () => {
     api.createScheduledJob.mockResolvedValue({ status: 'ok' });
     api.deleteScheduledJob.mockResolvedValue({ status: 'ok' });
     api.getK8sNamespaces.mockResolvedValue({ namespaces: ['default'] });
-    api.getK8sPods.mockResolvedValue(['pod-1']);
+    api.getK8sPods.mockResolvedValue([
+      {
+        name: 'pod-1',
+        namespace: 'default',
+        phase: 'Running',
+        ready: false,
+        ready_containers: 1,
+        restarts: 3,
+        owner: 'Deployment/web',
+        containers: [
+          { name: 'app', state: 'running', ready: true },
+          { name: 'sidecar', state: 'waiting', reason: 'CrashLoopBackOff', ready: false },
+        ],
+      }
+    ]);
   });
 
   it('renders and creates a new job', async () => {
@@ -77,6 +91,9 @@ describe('K8sLogScanner scheduled jobs', () => {
     await act(async () => {
       fireEvent.click(namespaceCheckbox);
     });
+    // The pod picker shows each pod's status and containers
+    expect(await screen.findByText('Running · 1/2 ready · Deployment/web · 3 restarts')).toBeInTheDocument();
+    expect(screen.getByText('app (running), sidecar (waiting: CrashLoopBackOff)')).toBeInTheDocument();
     // Fill in job name
     await act(async () => {
       fireEvent.change(screen.getByLabelText(/job name/i), { target: { value: 'My Job' } });
@@ -93,6 +110,7 @@ describe('K8sLogScanner scheduled jobs', () => {
     });
     await act(async () => {
       await waitFor(() => expect(api.createLogScanJob).toHaveBeenCalled(), { timeout: 2000 });
+      expect(api.createLogScanJob).toHaveBeenCalledWith(expect.objectContaining({ pods: ['pod-1'] }));
       await waitFor(() => expect(mockNotify).toHaveBeenCalled(), { timeout: 2000 });
     });
   });
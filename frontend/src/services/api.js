@@ -137,6 +137,7 @@ export const api = {
   async getK8sPods(cluster, namespace) {
     try {
       const response = await axios.get(`${API_BASE_URL}/k8s-pods?cluster=${encodeURIComponent(cluster)}&namespace=${encodeURIComponent(namespace)}`);
+      // Full pod summaries: phase, readiness, owner, restarts and containers
       return response.data.pods || [];
     } catch (error) {
       console.error('Error fetching K8s pods:', error);